@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"test/internal/config"
+	"test/internal/logger"
+	"test/internal/storage/migrations"
+	"test/internal/storage/postgres"
+)
+
+const usage = `Usage: migrate [flags] up|down|status
+
+  up      apply all pending migrations
+  down    roll back the last N applied migrations (default 1)
+  status  list migrations and whether they are applied
+
+Flags:
+`
+
+func main() {
+	steps := flag.Int("steps", 1, "number of migrations to roll back with down")
+	config.BindFlags(flag.CommandLine)
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Проверяются только БД и логи: настройки Kafka и кэша утилите не нужны
+	cfg, err := config.LoadDatabase()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	logg, err := logger.New(os.Stderr, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		log.Fatalf("Failed to create logger: %v", err)
+	}
+	storage, err := postgres.NewInstance(cfg.PostgresConnection.DataBasePath(), logg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer storage.Close()
+
+	db, err := storage.SQLDB()
+	if err != nil {
+		log.Fatalf("Failed to get database handle: %v", err)
+	}
+	migrator, err := migrations.New(db)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	switch flag.Arg(0) {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, mig := range applied {
+			fmt.Printf("applied  %04d_%s\n", mig.Version, mig.Name)
+		}
+		if err != nil {
+			log.Fatalf("Failed to migrate up: %v", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+	case "down":
+		rolledBack, err := migrator.Down(ctx, *steps)
+		for _, mig := range rolledBack {
+			fmt.Printf("reverted %04d_%s\n", mig.Version, mig.Name)
+		}
+		if err != nil {
+			log.Fatalf("Failed to migrate down: %v", err)
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatalf("Failed to get migration status: %v", err)
+		}
+		for _, st := range statuses {
+			state := "pending"
+			if st.Applied {
+				state = "applied at " + st.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-30s %s\n", st.Version, st.Name, state)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
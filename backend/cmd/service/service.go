@@ -2,24 +2,33 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
+	"sync"
 	"syscall"
 	"test/internal/config"
 	"test/internal/handlers/broker"
-	"test/internal/models"
+	"test/internal/handlers/rest"
+	"test/internal/health"
+	"test/internal/logger"
+	"test/internal/orders"
 	"test/internal/storage/cache"
+	"test/internal/storage/migrations"
 	"test/internal/storage/postgres"
+	"time"
 
 	"github.com/segmentio/kafka-go"
 )
 
-func ensureTopic(broker string, topics ...kafka.TopicConfig) error {
-	conn, err := kafka.Dial("tcp", broker)
+func ensureTopic(ctx context.Context, brokers []string, topics ...kafka.TopicConfig) error {
+	conn, err := broker.Dial(ctx, brokers)
 	if err != nil {
 		return err
 	}
@@ -40,150 +49,294 @@ func ensureTopic(broker string, topics ...kafka.TopicConfig) error {
 	return cconn.CreateTopics(topics...)
 }
 
-func main() {
-	var err error
-	var ctx context.Context = context.Background()
-	// Чтение кофигурационных файлов
-	cfg := config.MustLoad()
-	// Получение эземпляра базы данных
-	storage, err := postgres.NewInstance(cfg.PostgresConnection.DataBasePath())
+// Пауза между попытками сверить снимок кэша с недоступной БД
+const revalidateRetry = 5 * time.Second
+
+func migrateUp(ctx context.Context, log *slog.Logger, storage *postgres.Storage) error {
+	db, err := storage.SQLDB()
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		return err
 	}
+	migrator, err := migrations.New(db)
+	if err != nil {
+		return err
+	}
+	applied, err := migrator.Up(ctx)
+	for _, mig := range applied {
+		log.Info("migration applied", slog.Int64("version", mig.Version), slog.String("name", mig.Name))
+	}
+	return err
+}
+
+func main() {
+	// Корневой контекст отменяется по SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// Автоматическая миграция
-	err = storage.AutoMigrate()
+	// Чтение кофигурационных файлов, флаги перекрывают файл и окружение
+	config.BindFlags(flag.CommandLine)
+	flag.Parse()
+	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("Failed to auto migrate: %v", err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
-	// Инициализация кэша (Пробуем востановить, если не получается, то инициализируем новый)
-	var cacheInstance *cache.FifoCache
-	if cacheInstance = cache.RestoreCache(cfg.CacheParams.Path, cfg.CacheParams.Amount, storage); cacheInstance == nil {
-		cacheInstance = cache.NewFifoCache(cfg.CacheParams.Amount)
+	// Уровень логов меняется при перезагрузке конфига
+	var logLevel slog.LevelVar
+	level, err := logger.ParseLevel(cfg.Log.Level)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	logLevel.Set(level)
+	log, err := logger.NewWithLevel(os.Stdout, &logLevel, cfg.Log.Format)
+	if err != nil {
+		slog.Error("failed to create logger", logger.Err(err))
+		os.Exit(1)
+	}
+	// Ошибка на старте останавливает сервис
+	fatal := func(msg string, err error) {
+		log.Error(msg, logger.Err(err))
+		os.Exit(1)
 	}
 
-	// Организация топиков кафки
-	err = ensureTopic(cfg.Broker,
-		kafka.TopicConfig{
-			Topic:             "order_id",
-			NumPartitions:     3,
-			ReplicationFactor: 1,
-		},
-		kafka.TopicConfig{
-			Topic:             "json_data",
-			NumPartitions:     3,
-			ReplicationFactor: 1,
-		},
-		kafka.TopicConfig{
-			Topic:             "order_response",
-			NumPartitions:     3,
-			ReplicationFactor: 1,
-		},
-	)
+	// Получение эземпляра базы данных
+	storage, err := postgres.NewInstance(cfg.PostgresConnection.DataBasePath(), log)
 	if err != nil {
-		log.Fatalf("Failed to create topic: %v", err)
+		fatal("failed to connect to database", err)
 	}
 
-	// Подписка на топики:
-	// 1. топик order_id
-	go func() {
-		readerOrderId := kafka.NewReader(kafka.ReaderConfig{
-			// TODO: Сохранять поле Broker в config как список string
-			Brokers: []string{cfg.Broker},
-			Topic:   "order_id",
-			GroupID: "order-id-service-consumer",
-		})
-		responseWriterOrderID := kafka.Writer{
-			Addr:  kafka.TCP(cfg.Broker),
-			Topic: "order_response",
+	// Миграции схемы применяются до запуска консьюмеров
+	if err := migrateUp(ctx, log, storage); err != nil {
+		fatal("failed to migrate database", err)
+	}
+	// Проверки для /livez (процесс жив) и /readyz (готов обслуживать запросы)
+	live := health.NewChecker(cfg.Health.Timeout)
+	ready := health.NewChecker(cfg.Health.Timeout)
+	ready.Add("database", storage.Ping)
+	// Кэш готов, когда закончились фоновые сверка или прогрев, см. cacheTasks
+	var cacheRestored health.Flag
+	ready.Add("cache", cacheRestored.Check("cache restore in progress"))
+	var cacheTasks sync.WaitGroup
+
+	// Инициализация кэша с выбранной политикой вытеснения
+	orderCache, err := cache.New(cfg.CacheParams.Policy, cfg.CacheParams.Amount)
+	if err != nil {
+		fatal("failed to create cache", err)
+	}
+	// Пробуем востановить кэш, если не получается, то прогреваем последними заказами
+	// или работаем с пустым
+	var cacheInstance cache.Cache
+	closeCache := func() error { return nil }
+	restored := true
+	restoreFromDB := func() {
+		if err := cache.RestoreCache(ctx, log, orderCache, cfg.CacheParams.Path, storage); err != nil {
+			log.Warn("failed to restore cache", logger.Err(err))
+			restored = false
 		}
-		defer func() {
-			err = readerOrderId.Close()
-			if err != nil {
-				log.Fatalf("Failed to close id reader: %v", err)
-			}
-		}()
-		fmt.Println("Log: Servise is ready to get order_id messages")
-		for {
-			msg, err := readerOrderId.ReadMessage(context.Background())
+	}
+	switch cfg.CacheParams.Mode {
+	case cache.ModeJournal, cache.ModeSnapshot:
+		// Снимок и журнал не требуют БД; если их нет (например, файл еще в формате
+		// списка ключей), кэш восстанавливается из БД как раньше
+		var err error
+		if cfg.CacheParams.Mode == cache.ModeJournal {
+			err = cache.RestoreJournal(log, orderCache, cfg.CacheParams.Path)
+		} else {
+			err = cache.RestoreSnapshot(log, orderCache, cfg.CacheParams.Path)
+		}
+		if err != nil {
+			log.Warn("failed to restore cache from file, restoring from database", logger.Err(err))
+			restoreFromDB()
+		}
+
+		if cfg.CacheParams.Mode == cache.ModeJournal {
+			journal, err := cache.NewJournal(log, orderCache, cfg.CacheParams.Path, cfg.CacheParams.JournalCompactEvery)
 			if err != nil {
-				log.Fatalf("Failed to read message: %v", err)
+				fatal("failed to open cache journal", err)
 			}
-
-			// Получим интересуемый ID для поиска данных по заказу
-			orderIdStruct := models.OrderID{OrderUID: string(msg.Value)}
-			//orderIdStruct, err := broker.UnmarshalingOrderId(msg.Value)
-			//if err != nil {
-			//	log.Fatalf("Failed to unmarshal id message: %v", err)
-			//}
-			var takenData []byte
-			// Постараемся получить сообщение из кэша
-			// Делаем проверку на существование значения в кэше
-			if order, ok := cacheInstance.Get(orderIdStruct.OrderUID); ok {
-				takenData, err = broker.MarshalingOrderDataMessages(&order)
-				if err != nil {
-					log.Fatalf("Failed to marshal order: %v", err)
-				}
-			} else {
-				// Если заказ не был найден в кэше, то найдем его в Базе данных
-				// Поиск данных в бд, возвращаем туже модель models.Order
-				order, err := storage.GetOrderByUID(orderIdStruct.OrderUID)
-				if err != nil {
-					log.Fatalf("Failed to get order: %v", err)
-				}
-				// Преобразование модели models.Order в JSON для отправки в kafka
-				takenData, err = broker.MarshalingOrderDataMessages(order)
-				if err != nil {
-					log.Fatalf("Failed to marshal order: %v", err)
+			cacheInstance, closeCache = journal, journal.Close
+		} else {
+			cacheInstance = cache.NewSnapshotPersistent(log, orderCache, cfg.CacheParams.Path)
+		}
+		// Снимок мог устареть, пока сервис был остановлен: сверка идет в фоне,
+		// кэш обслуживает запросы сразу
+		if err == nil && cfg.CacheParams.Revalidate {
+			cacheTasks.Add(1)
+			go func() {
+				defer cacheTasks.Done()
+				if err := cache.Revalidate(ctx, log, cacheInstance, storage, revalidateRetry); err != nil {
+					log.Warn("cache revalidation stopped", logger.Err(err))
 				}
-			}
-			response := kafka.Message{
-				Value: takenData,
-			}
-			err = responseWriterOrderID.WriteMessages(ctx, response)
+			}()
+		}
+	default:
+		restoreFromDB()
+		cacheInstance = cache.NewPersistent(log, orderCache, cfg.CacheParams.Path)
+	}
+	// Прогрев идет в фоне и не задерживает старт. Сервис пишет в кэш через Warming,
+	// чтобы прогрев не затер изменения и удаления, сделанные во время него
+	if !restored && cfg.CacheParams.WarmUp != cache.WarmUpNone {
+		warming := cache.NewWarming(cacheInstance)
+		cacheInstance = warming
+		cacheTasks.Add(1)
+		go func() {
+			defer cacheTasks.Done()
+			err := cache.WarmUp(ctx, log, warming, storage, cfg.CacheParams.WarmUp, cfg.CacheParams.WarmUpSize())
 			if err != nil {
-				log.Fatalf("Failed to write message: %v", err)
+				log.Warn("failed to warm up cache, starting empty", logger.Err(err))
 			}
-			fmt.Println(string(msg.Value))
-		}
+		}()
+	}
+	go func() {
+		cacheTasks.Wait()
+		cacheRestored.Set()
 	}()
+	// Поиск заказов: сначала кэш, затем БД. Переходы статусов проверяются по конфигу
+	ordersService := orders.NewService(cacheInstance, storage, orders.NewStatusMachine(cfg.OrderStatus.Transitions))
+	ordersService.SetErasureMode(cfg.Erasure.Mode)
+
+	// Организация топиков кафки
+	kafkaCfg := cfg.Kafka
+	topics := kafkaCfg.Topics
+	err = ensureTopic(ctx, kafkaCfg.Brokers, broker.TopicConfigs(topics.All()...)...)
+	if err != nil {
+		fatal("failed to create topics", err)
+	}
+	ready.Add("kafka", func(ctx context.Context) error {
+		return broker.CheckTopics(ctx, kafkaCfg.Brokers,
+			topics.OrderID.Name, topics.JSONData.Name, topics.OrderResponse.Name, topics.OrderStatus.Name, topics.OrderErasure.Name, topics.DeadLetter.Name)
+	})
+
+	// Подписка на топики:
+	// 1. топик order_id
+	readerOrderId := broker.NewReader(kafkaCfg, topics.OrderID, kafkaCfg.Groups.OrderID)
+	// Топик не задается у writer: он выбирается для каждого ответа по заголовку reply_to
+	// из списка разрешенных (kafka.reply_topics)
+	responseWriterOrderID := broker.NewWriter(kafkaCfg)
+	orderIDHandler := broker.NewOrderIDHandler(log, ordersService, responseWriterOrderID, topics.OrderResponse.Name, kafkaCfg.ReplyTopics...)
+
 	// 2. топик json_data
-	go func() {
-		readerOrderJson := kafka.NewReader(kafka.ReaderConfig{
-			Brokers: []string{cfg.Broker},
-			Topic:   "json_data",
-			GroupID: "service-json-data-consumer",
-		})
-		defer func() {
-			err = readerOrderJson.Close()
-			if err != nil {
-				log.Fatalf("Failed to close json reader: %v", err)
+	readerOrderJson := broker.NewReader(kafkaCfg, topics.JSONData, kafkaCfg.Groups.JSONData)
+	deadLetterWriter := broker.NewWriter(kafkaCfg)
+	// Сообщения, которые не удалось разобрать или сохранить, уходят в dead-letter топик
+	jsonDataHandler := broker.NewJSONDataHandler(log, ordersService, deadLetterWriter, topics.DeadLetter.Name)
+
+	// 3. топик order_status
+	readerOrderStatus := broker.NewReader(kafkaCfg, topics.OrderStatus, kafkaCfg.Groups.OrderStatus)
+	// Отклоненные обновления статусов уходят в тот же dead-letter топик, источник виден в заголовках
+	orderStatusHandler := broker.NewOrderStatusHandler(log, ordersService, deadLetterWriter, topics.DeadLetter.Name)
+
+	// 4. топик order_erasure
+	readerOrderErasure := broker.NewReader(kafkaCfg, topics.OrderErasure, kafkaCfg.Groups.OrderErasure)
+	orderErasureHandler := broker.NewOrderErasureHandler(log, ordersService, deadLetterWriter, topics.DeadLetter.Name)
+
+	// Heartbeat консьюмеров проверяется и в /livez, и в /readyz
+	var orderIDHeartbeat, jsonDataHeartbeat, orderStatusHeartbeat, orderErasureHeartbeat health.Heartbeat
+	for _, checker := range []*health.Checker{live, ready} {
+		checker.Add("consumer_order_id", orderIDHeartbeat.Check(cfg.Health.HeartbeatTimeout))
+		checker.Add("consumer_json_data", jsonDataHeartbeat.Check(cfg.Health.HeartbeatTimeout))
+		checker.Add("consumer_order_status", orderStatusHeartbeat.Check(cfg.Health.HeartbeatTimeout))
+		checker.Add("consumer_order_erasure", orderErasureHeartbeat.Check(cfg.Health.HeartbeatTimeout))
+	}
+
+	orderIDConsumer := broker.NewConsumer(log, topics.OrderID.Name, readerOrderId, orderIDHandler, &orderIDHeartbeat)
+	orderIDConsumer.SetWorkers(cfg.Consumer.OrderID.Concurrency, cfg.Consumer.OrderID.QueueSize, cfg.Consumer.OrderID.OrderBy)
+	jsonDataConsumer := broker.NewConsumer(log, topics.JSONData.Name, readerOrderJson, jsonDataHandler, &jsonDataHeartbeat)
+	jsonDataConsumer.SetWorkers(cfg.Consumer.JSONData.Concurrency, cfg.Consumer.JSONData.QueueSize, cfg.Consumer.JSONData.OrderBy)
+	orderStatusConsumer := broker.NewConsumer(log, topics.OrderStatus.Name, readerOrderStatus, orderStatusHandler, &orderStatusHeartbeat)
+	orderStatusConsumer.SetWorkers(cfg.Consumer.OrderStatus.Concurrency, cfg.Consumer.OrderStatus.QueueSize, cfg.Consumer.OrderStatus.OrderBy)
+	orderErasureConsumer := broker.NewConsumer(log, topics.OrderErasure.Name, readerOrderErasure, orderErasureHandler, &orderErasureHeartbeat)
+	orderErasureConsumer.SetWorkers(cfg.Consumer.OrderErasure.Concurrency, cfg.Consumer.OrderErasure.QueueSize, cfg.Consumer.OrderErasure.OrderBy)
+	consumerList := []*broker.Consumer{orderIDConsumer, jsonDataConsumer, orderStatusConsumer, orderErasureConsumer}
+	var consumers sync.WaitGroup
+	for _, consumer := range consumerList {
+		consumer.SetPollInterval(cfg.Consumer.PollInterval)
+		consumer.SetCommitBatch(cfg.Consumer.CommitBatchSize, cfg.Consumer.CommitInterval)
+		consumers.Add(1)
+		go func() {
+			defer consumers.Done()
+			if err := consumer.Run(ctx); err != nil {
+				log.Error("consumer stopped", logger.Err(err))
+				// Остановившийся консьюмер останавливает весь сервис
+				stop()
 			}
 		}()
-		fmt.Println("Log: Servise is ready to get json_data messages")
-		for {
-			msg, err := readerOrderJson.ReadMessage(context.Background())
-			if err != nil {
-				log.Fatalf("Failed to read message: %v", err)
-			}
-			order, err := broker.UnmarshalingOrderDataMessages(msg.Value)
-			if err != nil {
-				log.Fatalf("Failed to unmarshal json data: %v", err)
-			}
-			// Запись в бд
-			err = storage.NewDataLoad(order)
-			if err != nil {
-				log.Fatalf("Failed to store order: %v", err)
-			}
-			// Сохранение в кэш
-			cacheInstance.Set(cfg, order.OrderUID, *order)
+	}
+
+	// Перезагрузка конфига по SIGHUP и при изменении файла: применяются только
+	// размер кэша, уровень логов и настройки консьюмеров
+	watcher := config.NewWatcher(log, cfg, func(next *config.Config) {
+		if level, err := logger.ParseLevel(next.Log.Level); err == nil {
+			logLevel.Set(level)
+		}
+		cacheInstance.Resize(next.CacheParams.Amount)
+		for _, consumer := range consumerList {
+			consumer.SetPollInterval(next.Consumer.PollInterval)
+			consumer.SetCommitBatch(next.Consumer.CommitBatchSize, next.Consumer.CommitInterval)
+		}
+	})
+	go watcher.Run(ctx)
+
+	// HTTP сервер для синхронного получения, поиска и удаления заказов
+	srv := &http.Server{
+		Addr:         cfg.HTTPServer.Address,
+		Handler:      rest.NewRouter(log, ordersService, ordersService, ordersService, cfg.HTTPServer.ErasureToken, live, ready),
+		ReadTimeout:  cfg.HTTPServer.Timeout,
+		WriteTimeout: cfg.HTTPServer.Timeout,
+		IdleTimeout:  cfg.HTTPServer.IdleTimeout,
+	}
+	go func() {
+		log.Info("http server started", slog.String("address", cfg.HTTPServer.Address))
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("http server stopped", logger.Err(err))
+			stop()
 		}
 	}()
 
 	// Закрытие сервиса
-	exit := make(chan os.Signal, 1)
-	// Подписка канала на получение сигналов:
-	signal.Notify(exit, os.Interrupt, syscall.SIGTERM)
-	<-exit
-	fmt.Println("Server was shut down")
+	<-ctx.Done()
+	log.Info("shutting down", slog.Duration("timeout", cfg.ShutdownTimeout))
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Warn("failed to shut down http server", logger.Err(err))
+	}
+
+	// Ждем, пока консьюмеры дообработают уже прочитанные сообщения, а прогрев
+	// и сверка кэша остановятся: после закрытия журнала их записи в файлы не попадут
+	drained := make(chan struct{})
+	go func() {
+		consumers.Wait()
+		cacheTasks.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-shutdownCtx.Done():
+		log.Warn("consumers and cache tasks did not finish in time, closing anyway")
+	}
+
+	// Порядок закрытия: читатели, писатели (сбрасывают буферы), журнал кэша
+	// (сжимается в снимок), пул соединений с БД
+	closers := []struct {
+		name  string
+		close func() error
+	}{
+		{"order_id reader", readerOrderId.Close},
+		{"json_data reader", readerOrderJson.Close},
+		{"order_status reader", readerOrderStatus.Close},
+		{"order_erasure reader", readerOrderErasure.Close},
+		{"order_response writer", responseWriterOrderID.Close},
+		{"dead letter writer", deadLetterWriter.Close},
+		{"cache journal", closeCache},
+		{"database", storage.Close},
+	}
+	for _, c := range closers {
+		if err := c.close(); err != nil {
+			log.Warn("failed to close", slog.String("resource", c.name), logger.Err(err))
+		}
+	}
+	log.Info("service stopped")
 }
@@ -1,21 +1,25 @@
 package main
 
 import (
-	"github.com/segmentio/kafka-go"
+	"context"
+	"test/internal/handlers/broker"
 	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
 )
 
 func Test_ensureTopic(t *testing.T) {
 	type args struct {
-		broker string
-		cfg    kafka.TopicConfig
+		brokers []string
+		cfg     kafka.TopicConfig
 	}
 	tests := []struct {
 		args args
 	}{
 		{
 			args: args{
-				broker: "localhost:9092",
+				brokers: []string{"localhost:9092"},
 				cfg: kafka.TopicConfig{
 					Topic:             "order_id",
 					NumPartitions:     3,
@@ -25,7 +29,16 @@ func Test_ensureTopic(t *testing.T) {
 		},
 	}
 	for _, tt := range tests {
-		err := ensureTopic(tt.args.broker, tt.args.cfg)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		conn, err := broker.Dial(ctx, tt.args.brokers)
+		if err != nil {
+			cancel()
+			t.Skipf("kafka is not available: %v", err)
+		}
+		conn.Close()
+
+		err = ensureTopic(ctx, tt.args.brokers, tt.args.cfg)
+		cancel()
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -1,63 +1,273 @@
 package config
 
 import (
+	"errors"
 	"fmt"
-	"github.com/ilyakaznacheev/cleanenv"
-	"github.com/joho/godotenv"
+	"io/fs"
 	"log"
 	"os"
+	"time"
+
+	"github.com/ilyakaznacheev/cleanenv"
+	"github.com/joho/godotenv"
 )
 
-// TODO: Сохранять поле Broker в config как список string
 // Config структура
 type Config struct {
 	HTTPServer         `yaml:"http_server"`
 	PostgresConnection `yaml:"db_path"`
-	Broker             string `yaml:"broker"`
+	Kafka              Kafka `yaml:"kafka"`
 	CacheParams        `yaml:"cache_params"`
+	// Сколько ждать дообработки сообщений и HTTP запросов при остановке
+	ShutdownTimeout time.Duration  `yaml:"shutdown_timeout" env-default:"10s"`
+	Log             LogParams      `yaml:"log"`
+	Health          HealthParams   `yaml:"health"`
+	Consumer        ConsumerParams `yaml:"consumer"`
+	Reload          ReloadParams   `yaml:"reload"`
+	OrderStatus     StatusParams   `yaml:"order_status"`
+	Erasure         ErasureParams  `yaml:"erasure"`
+}
+
+// ErasureParams - удаление заказов по запросам на удаление персональных данных
+type ErasureParams struct {
+	// delete - заказ удаляется целиком; anonymize - очищаются customer_id и контакты доставки
+	Mode string `yaml:"mode" env-default:"delete"`
+}
+
+// StatusParams - машина состояний статусов товаров для топика order_status
+type StatusParams struct {
+	// Статус -> статусы, в которые из него можно перейти. Статус без переходов конечный.
+	// Если не задано, используются defaultTransitions
+	Transitions map[int][]int `yaml:"transitions"`
+}
+
+// Переходы по умолчанию: 202 принят -> 203 отправлен или 205 отменен, 203 отправлен -> 204 доставлен
+var defaultTransitions = map[int][]int{
+	202: {203, 205},
+	203: {204},
+}
+
+func (p *StatusParams) setDefaults() {
+	if len(p.Transitions) == 0 {
+		p.Transitions = make(map[int][]int, len(defaultTransitions))
+		for from, to := range defaultTransitions {
+			p.Transitions[from] = append([]int(nil), to...)
+		}
+	}
+}
+
+// ConsumerParams - настройки консьюмеров. Без перезапуска меняются все, кроме пулов обработчиков
+type ConsumerParams struct {
+	// Таймаут одного чтения: с такой частотой консьюмер без сообщений отмечает heartbeat
+	PollInterval time.Duration `yaml:"poll_interval" env-default:"5s"`
+	// Оффсеты коммитятся после обработки пачкой: когда накопилось CommitBatchSize
+	// сообщений или прошло CommitInterval с первого незакоммиченного
+	CommitBatchSize int           `yaml:"commit_batch_size" env-default:"100"`
+	CommitInterval  time.Duration `yaml:"commit_interval" env-default:"1s"`
+	OrderID         WorkerParams  `yaml:"order_id"`
+	JSONData        WorkerParams  `yaml:"json_data"`
+	OrderStatus     WorkerParams  `yaml:"order_status"`
+	OrderErasure    WorkerParams  `yaml:"order_erasure"`
+}
+
+// WorkerParams - пул обработчиков топика
+type WorkerParams struct {
+	// Сколько сообщений обрабатывается параллельно
+	Concurrency int `yaml:"concurrency" env-default:"3"`
+	// Длина очереди каждого обработчика: при заполнении чтение из kafka ждет
+	QueueSize int `yaml:"queue_size" env-default:"16"`
+	// partition или key: сообщения одной партиции или одного ключа (order_uid)
+	// обрабатываются последовательно
+	OrderBy string `yaml:"order_by" env-default:"partition"`
+}
+
+type ReloadParams struct {
+	// Как часто проверять изменение файла конфига, 0 - только по SIGHUP
+	Interval time.Duration `yaml:"interval" env-default:"5s"`
+}
+
+type Kafka struct {
+	Brokers  []string `yaml:"brokers" env:"KAFKA_BROKERS" env-separator:"," env-default:"localhost:9092"`
+	ClientID string   `yaml:"client_id" env-default:"order-service"`
+	// Параметры чтения: минимальный и максимальный размер пачки
+	MinBytes int         `yaml:"min_bytes" env-default:"1"`
+	MaxBytes int         `yaml:"max_bytes" env-default:"10485760"`
+	Topics   KafkaTopics `yaml:"topics"`
+	Groups   KafkaGroups `yaml:"groups"`
+	// Топики, которые клиент может указать в заголовке reply_to запроса order_id,
+	// кроме topics.order_response. Топики, которые читает сервис, не допускаются
+	ReplyTopics []string `yaml:"reply_topics"`
+}
+
+type KafkaTopics struct {
+	OrderID       TopicParams `yaml:"order_id"`
+	JSONData      TopicParams `yaml:"json_data"`
+	OrderResponse TopicParams `yaml:"order_response"`
+	// Обновления статусов товаров и заказов
+	OrderStatus TopicParams `yaml:"order_status"`
+	// Запросы на удаление заказов
+	OrderErasure TopicParams `yaml:"order_erasure"`
+	// Топик для сообщений json_data, order_status и order_erasure, которые не удалось разобрать или применить
+	DeadLetter TopicParams `yaml:"dead_letter"`
+}
+
+// All возвращает все топики сервиса
+func (t KafkaTopics) All() []TopicParams {
+	return []TopicParams{t.OrderID, t.JSONData, t.OrderResponse, t.OrderStatus, t.OrderErasure, t.DeadLetter}
+}
+
+type TopicParams struct {
+	Name        string `yaml:"name"`
+	Partitions  int    `yaml:"partitions" env-default:"3"`
+	Replication int    `yaml:"replication" env-default:"1"`
+	// 0 - значение брокера по умолчанию
+	Retention time.Duration `yaml:"retention"`
+}
+
+type KafkaGroups struct {
+	OrderID      string `yaml:"order_id" env-default:"order-id-service-consumer"`
+	JSONData     string `yaml:"json_data" env-default:"service-json-data-consumer"`
+	OrderStatus  string `yaml:"order_status" env-default:"service-order-status-consumer"`
+	OrderErasure string `yaml:"order_erasure" env-default:"service-order-erasure-consumer"`
+}
+
+// setDefaults задает имена топиков, которые не указаны в конфиге
+func (k *Kafka) setDefaults() {
+	for _, topic := range []struct {
+		params *TopicParams
+		name   string
+	}{
+		{&k.Topics.OrderID, "order_id"},
+		{&k.Topics.JSONData, "json_data"},
+		{&k.Topics.OrderResponse, "order_response"},
+		{&k.Topics.OrderStatus, "order_status"},
+		{&k.Topics.OrderErasure, "order_erasure"},
+		{&k.Topics.DeadLetter, "json_data.dlq"},
+	} {
+		if topic.params.Name == "" {
+			topic.params.Name = topic.name
+		}
+	}
+}
+
+type HealthParams struct {
+	// Общий таймаут проверок /livez и /readyz
+	Timeout time.Duration `yaml:"timeout" env-default:"2s"`
+	// Через сколько без heartbeat консьюмер считается зависшим
+	HeartbeatTimeout time.Duration `yaml:"heartbeat_timeout" env-default:"30s"`
+}
+
+type LogParams struct {
+	// debug, info, warn или error
+	Level string `yaml:"level" env:"LOG_LEVEL" env-default:"info"`
+	// text или json
+	Format string `yaml:"format" env:"LOG_FORMAT" env-default:"text"`
 }
 
 type CacheParams struct {
+	// Вместимость кэша, должна быть больше 0
 	Amount int    `yaml:"amount"`
 	Path   string `yaml:"path" env:"CACHE_PATH"`
+	// Политика вытеснения: fifo, lru или lfu
+	Policy string `yaml:"policy" env-default:"fifo"`
+	// keys - в файле только ключи, значения при старте читаются из БД;
+	// snapshot - в файле заказы целиком, кэш восстанавливается без БД;
+	// journal - изменения дописываются в журнал path.journal и периодически сжимаются в снимок
+	Mode string `yaml:"mode" env-default:"journal"`
+	// Через сколько записей журнал сжимается в снимок
+	JournalCompactEvery int `yaml:"journal_compact_every" env-default:"1000"`
+	// Сверить восстановленный снимок с БД в фоне (режимы snapshot и journal)
+	Revalidate bool `yaml:"revalidate"`
+	// Если кэш не удалось восстановить из файла, в фоне загружаются последние заказы:
+	// none, date_created или created_at
+	WarmUp string `yaml:"warm_up" env-default:"none"`
+	// Сколько заказов загружать при прогреве, 0 - amount. Больше amount не загружается
+	WarmUpLimit int `yaml:"warm_up_limit"`
+}
+
+// WarmUpSize - число заказов для прогрева, ограниченное вместимостью кэша
+func (p CacheParams) WarmUpSize() int {
+	if p.WarmUpLimit <= 0 || p.WarmUpLimit > p.Amount {
+		return p.Amount
+	}
+	return p.WarmUpLimit
 }
 
 type PostgresConnection struct {
 	Host     string `yaml:"host"`
-	Port     string `yaml:"port" env:"PORT" env-required:"true"`
-	DBName   string `yaml:"dbname" env:"DB_NAME" env-required:"true"`
+	Port     string `yaml:"port" env:"PORT"`
+	DBName   string `yaml:"dbname" env:"DB_NAME"`
 	SSLMode  string `yaml:"ssl_mode" env:"SSL_MODE"`
-	Username string `yaml:"username" env:"DB_USER" env-required:"true"`
-	Password string `yaml:"password" env:"DB_PASSWORD" env-required:"true"`
+	Username string `yaml:"username" env:"DB_USER"`
+	Password string `yaml:"password" env:"DB_PASSWORD"`
 }
 
 type HTTPServer struct {
-	Address string `yaml:"address" env-default:"localhost:8081"`
+	Address     string        `yaml:"address" env-default:"localhost:8081"`
+	Timeout     time.Duration `yaml:"timeout" env-default:"4s"`
+	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"60s"`
+	// Токен для DELETE /orders/{order_uid} (заголовок Authorization: Bearer). Без токена
+	// удаление по HTTP выключено, остается только топик order_erasure
+	ErasureToken string `yaml:"erasure_token" env:"HTTP_ERASURE_TOKEN"`
 }
 
-func MustLoad() *Config {
-	const op = "config.config.MustLoad"
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatalf("Loc: %s, Err: %v", op, err)
+// Load читает конфигурацию: .env (если есть), yaml-файл из CONFIG_PATH или флага -config,
+// переменные окружения и флаги командной строки. Ошибки проверки возвращаются все сразу
+func Load() (*Config, error) {
+	return load("config.Load", (*Config).Validate)
+}
+
+// LoadDatabase - Load для утилит, которым нужна только БД (например, migrate):
+// проверяются только db_path и log, см. ValidateDatabase
+func LoadDatabase() (*Config, error) {
+	return load("config.LoadDatabase", (*Config).ValidateDatabase)
+}
+
+func load(op string, validate func(*Config) error) (*Config, error) {
+	// В контейнере переменные уже заданы в окружении, поэтому .env не обязателен
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%s: read .env: %w", op, err)
 	}
 
-	configPath := os.Getenv("CONFIG_PATH")
+	configPath := Path()
 	if configPath == "" {
-		log.Fatalf("CONFIG_PATH is not set")
+		return nil, fmt.Errorf("%s: CONFIG_PATH is not set", op)
 	}
-
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		log.Fatalf("config file %s does not exist", configPath)
+	if _, err := os.Stat(configPath); err != nil {
+		return nil, fmt.Errorf("%s: config file %s: %w", op, configPath, err)
 	}
 
 	var cfg Config
-
 	if err := cleanenv.ReadConfig(configPath, &cfg); err != nil {
-		log.Fatalf("cannot read config: %s", err)
+		return nil, fmt.Errorf("%s: cannot read config: %w", op, err)
+	}
+	cfg.Kafka.setDefaults()
+	cfg.OrderStatus.setDefaults()
+
+	if err := flagValues.apply(&cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := validate(&cfg); err != nil {
+		return nil, fmt.Errorf("%s: invalid config:\n%w", op, err)
 	}
+	return &cfg, nil
+}
+
+// Path возвращает путь к файлу конфига: флаг -config или CONFIG_PATH
+func Path() string {
+	if flagValues.configPath != "" {
+		return flagValues.configPath
+	}
+	return os.Getenv("CONFIG_PATH")
+}
 
-	return &cfg
+// MustLoad - Load, который завершает процесс при ошибке
+func MustLoad() *Config {
+	cfg, err := Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return cfg
 }
 
 func (p *PostgresConnection) DataBasePath() (storagePath string) {
@@ -0,0 +1,291 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testConfig = `
+http_server:
+  address: "localhost:8081"
+db_path:
+  host: "localhost"
+  port: "5432"
+  dbname: "service"
+  username: "postgres"
+  password: "secret"
+kafka:
+  brokers: ["localhost:9092"]
+cache_params:
+  amount: 20
+  path: "cache.json"
+`
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// resetFlags сбрасывает значения флагов, оставшиеся от предыдущего теста
+func resetFlags(t *testing.T) {
+	t.Helper()
+	flagValues = flagSet{}
+	t.Cleanup(func() { flagValues = flagSet{} })
+}
+
+func TestLoadWithoutDotEnv(t *testing.T) {
+	resetFlags(t)
+	// В рабочей директории теста нет .env, все значения берутся из файла и окружения
+	t.Setenv("CONFIG_PATH", writeConfig(t, testConfig))
+	t.Setenv("LOG_LEVEL", "debug")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Log.Level != "debug" {
+		t.Errorf("Log.Level = %q, want debug", cfg.Log.Level)
+	}
+	if cfg.Kafka.Topics.DeadLetter.Name != "json_data.dlq" || cfg.Kafka.Topics.OrderID.Partitions != 3 {
+		t.Errorf("kafka defaults not applied: %+v", cfg.Kafka.Topics)
+	}
+	if cfg.CacheParams.Policy != "fifo" {
+		t.Errorf("CacheParams.Policy = %q, want fifo", cfg.CacheParams.Policy)
+	}
+}
+
+func TestLoadFlagOverrides(t *testing.T) {
+	resetFlags(t)
+	t.Setenv("CONFIG_PATH", "does-not-exist.yaml")
+	t.Setenv("LOG_LEVEL", "debug")
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	BindFlags(flags)
+	err := flags.Parse([]string{
+		"-config", writeConfig(t, testConfig),
+		"-log-level", "warn",
+		"-cache-amount", "50",
+		"-kafka-brokers", "k1:9092,k2:9092",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Log.Level != "warn" {
+		t.Errorf("Log.Level = %q, want warn", cfg.Log.Level)
+	}
+	if cfg.CacheParams.Amount != 50 {
+		t.Errorf("CacheParams.Amount = %d, want 50", cfg.CacheParams.Amount)
+	}
+	if got := strings.Join(cfg.Kafka.Brokers, ","); got != "k1:9092,k2:9092" {
+		t.Errorf("Kafka.Brokers = %s", got)
+	}
+}
+
+func TestLoadReportsAllErrors(t *testing.T) {
+	resetFlags(t)
+	t.Setenv("CONFIG_PATH", writeConfig(t, `
+db_path:
+  host: "localhost"
+  port: "postgres"
+cache_params:
+  amount: 0
+  policy: "random"
+erasure:
+  mode: "forget"
+kafka:
+  min_bytes: 100
+  max_bytes: 10
+  groups:
+    order_id: "same"
+    json_data: "same"
+`))
+	for _, env := range []string{"DB_NAME", "DB_USER", "DB_PASSWORD", "CACHE_PATH", "KAFKA_BROKERS"} {
+		t.Setenv(env, "")
+		os.Unsetenv(env)
+	}
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("Load() error = nil, want validation errors")
+	}
+	for _, field := range []string{
+		"db_path.port",
+		"db_path.dbname",
+		"db_path.username",
+		"db_path.password",
+		"cache_params.amount",
+		"cache_params.policy",
+		"cache_params.path",
+		"kafka.max_bytes",
+		"kafka.groups",
+		"erasure.mode",
+	} {
+		if !strings.Contains(err.Error(), field+":") {
+			t.Errorf("error does not mention %s:\n%v", field, err)
+		}
+	}
+}
+
+func TestLoadDatabase(t *testing.T) {
+	resetFlags(t)
+	// Кэш и Kafka настроены неверно, но для миграций это неважно
+	t.Setenv("CONFIG_PATH", writeConfig(t, `
+db_path:
+  host: "localhost"
+  port: "5432"
+  dbname: "service"
+  username: "postgres"
+  password: "secret"
+cache_params:
+  amount: 0
+kafka:
+  min_bytes: 100
+  max_bytes: 10
+`))
+	t.Setenv("CACHE_PATH", "")
+	os.Unsetenv("CACHE_PATH")
+
+	if _, err := LoadDatabase(); err != nil {
+		t.Fatalf("LoadDatabase() error = %v", err)
+	}
+	if _, err := Load(); err == nil {
+		t.Error("Load() error = nil, want cache and kafka errors")
+	}
+
+	t.Setenv("DB_PASSWORD", "")
+	t.Setenv("LOG_LEVEL", "trace")
+	_, err := LoadDatabase()
+	if err == nil {
+		t.Fatal("LoadDatabase() error = nil, want validation errors")
+	}
+	for _, field := range []string{"db_path.password", "log.level"} {
+		if !strings.Contains(err.Error(), field+":") {
+			t.Errorf("error does not mention %s:\n%v", field, err)
+		}
+	}
+	if strings.Contains(err.Error(), "cache_params") {
+		t.Errorf("LoadDatabase() checked cache settings:\n%v", err)
+	}
+}
+
+func TestValidateTopicNames(t *testing.T) {
+	cfg := validConfig()
+	cfg.Kafka.Topics.DeadLetter.Name = cfg.Kafka.Topics.JSONData.Name
+	cfg.Kafka.Topics.OrderID.Partitions = 0
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() error = nil")
+	}
+	for _, want := range []string{"kafka.topics.dead_letter.name", "kafka.topics.order_id.partitions"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not mention %s:\n%v", want, err)
+		}
+	}
+
+	if err := validConfig().Validate(); err != nil {
+		t.Errorf("valid config: %v", err)
+	}
+
+	cfg = validConfig()
+	cfg.Kafka.ReplyTopics = []string{"order_response.client1", cfg.Kafka.Topics.OrderID.Name}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "kafka.reply_topics[1]") {
+		t.Errorf("reply topic consumed by the service: error = %v, want kafka.reply_topics[1]", err)
+	}
+}
+
+func TestLoadStatusTransitions(t *testing.T) {
+	resetFlags(t)
+	t.Setenv("CONFIG_PATH", writeConfig(t, testConfig))
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := cfg.OrderStatus.Transitions[202]; len(got) != 2 {
+		t.Errorf("default transitions from 202 = %v, want [203 205]", got)
+	}
+
+	t.Setenv("CONFIG_PATH", writeConfig(t, testConfig+`
+order_status:
+  transitions:
+    202: [210]
+    210: [220, 230]
+`))
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(cfg.OrderStatus.Transitions) != 2 || len(cfg.OrderStatus.Transitions[210]) != 2 {
+		t.Errorf("Transitions = %v, want configured ones only", cfg.OrderStatus.Transitions)
+	}
+
+	bad := validConfig()
+	bad.OrderStatus.Transitions = map[int][]int{202: {202, -1}}
+	err = bad.Validate()
+	if err == nil || !strings.Contains(err.Error(), "order_status.transitions[202]") {
+		t.Errorf("Validate() error = %v, want order_status.transitions[202]", err)
+	}
+}
+
+func validConfig() *Config {
+	cfg := &Config{
+		HTTPServer: HTTPServer{Address: "localhost:8081", Timeout: 4 * time.Second, IdleTimeout: time.Minute},
+		PostgresConnection: PostgresConnection{
+			Host: "localhost", Port: "5432", DBName: "service", Username: "postgres", Password: "secret",
+		},
+		Kafka: Kafka{
+			Brokers: []string{"localhost:9092"}, ClientID: "order-service", MinBytes: 1, MaxBytes: 10,
+			Groups: KafkaGroups{OrderID: "a", JSONData: "b", OrderStatus: "c", OrderErasure: "d"},
+		},
+		CacheParams:     CacheParams{Amount: 10, Path: "cache.json", Policy: "lru", Mode: "journal", JournalCompactEvery: 1000, Revalidate: true, WarmUp: "none"},
+		ShutdownTimeout: 10 * time.Second,
+		Log:             LogParams{Level: "info", Format: "text"},
+		Health:          HealthParams{Timeout: 2 * time.Second, HeartbeatTimeout: 30 * time.Second},
+		Consumer: ConsumerParams{
+			PollInterval: 5 * time.Second, CommitBatchSize: 100, CommitInterval: time.Second,
+			OrderID:      WorkerParams{Concurrency: 3, QueueSize: 16, OrderBy: "key"},
+			JSONData:     WorkerParams{Concurrency: 3, QueueSize: 16, OrderBy: "partition"},
+			OrderStatus:  WorkerParams{Concurrency: 3, QueueSize: 16, OrderBy: "key"},
+			OrderErasure: WorkerParams{Concurrency: 1, QueueSize: 16, OrderBy: "key"},
+		},
+		Erasure: ErasureParams{Mode: "delete"},
+	}
+	for _, t := range []*TopicParams{
+		&cfg.Kafka.Topics.OrderID, &cfg.Kafka.Topics.JSONData,
+		&cfg.Kafka.Topics.OrderResponse, &cfg.Kafka.Topics.OrderStatus, &cfg.Kafka.Topics.OrderErasure,
+		&cfg.Kafka.Topics.DeadLetter,
+	} {
+		t.Partitions, t.Replication = 1, 1
+	}
+	cfg.Kafka.setDefaults()
+	cfg.OrderStatus.setDefaults()
+	return cfg
+}
+
+func TestCacheParams_WarmUpSize(t *testing.T) {
+	for _, tt := range []struct {
+		limit, amount, want int
+	}{
+		{limit: 0, amount: 20, want: 20},
+		{limit: 5, amount: 20, want: 5},
+		{limit: 50, amount: 20, want: 20},
+	} {
+		p := CacheParams{Amount: tt.amount, WarmUpLimit: tt.limit}
+		if got := p.WarmUpSize(); got != tt.want {
+			t.Errorf("WarmUpSize(limit=%d, amount=%d) = %d, want %d", tt.limit, tt.amount, got, tt.want)
+		}
+	}
+}
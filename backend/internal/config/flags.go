@@ -0,0 +1,95 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// override - флаг командной строки, который перекрывает значение из файла и окружения
+type override struct {
+	name  string
+	usage string
+	apply func(cfg *Config, value string) error
+}
+
+var overrides = []override{
+	{"http-address", "HTTP listen address", func(cfg *Config, v string) error {
+		cfg.HTTPServer.Address = v
+		return nil
+	}},
+	{"log-level", "log level: debug, info, warn or error", func(cfg *Config, v string) error {
+		cfg.Log.Level = v
+		return nil
+	}},
+	{"log-format", "log format: text or json", func(cfg *Config, v string) error {
+		cfg.Log.Format = v
+		return nil
+	}},
+	{"cache-amount", "cache capacity", func(cfg *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		cfg.CacheParams.Amount = n
+		return nil
+	}},
+	{"cache-policy", "cache eviction policy: fifo, lru or lfu", func(cfg *Config, v string) error {
+		cfg.CacheParams.Policy = v
+		return nil
+	}},
+	{"cache-path", "path to the cache metadata file", func(cfg *Config, v string) error {
+		cfg.CacheParams.Path = v
+		return nil
+	}},
+	{"kafka-brokers", "comma-separated list of Kafka brokers", func(cfg *Config, v string) error {
+		cfg.Kafka.Brokers = strings.Split(v, ",")
+		return nil
+	}},
+	{"shutdown-timeout", "graceful shutdown timeout", func(cfg *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		cfg.ShutdownTimeout = d
+		return nil
+	}},
+}
+
+// flagSet - значения флагов, заданных при запуске
+type flagSet struct {
+	configPath string
+	values     []flagValue
+}
+
+type flagValue struct {
+	override
+	value string
+}
+
+var flagValues flagSet
+
+// BindFlags регистрирует флаги конфигурации в flags. Вызывается до flags.Parse,
+// значения применяются в Load поверх файла и переменных окружения
+func BindFlags(flags *flag.FlagSet) {
+	flags.StringVar(&flagValues.configPath, "config", "", "path to the yaml config, overrides CONFIG_PATH")
+	for _, o := range overrides {
+		flags.Func(o.name, o.usage, func(v string) error {
+			flagValues.values = append(flagValues.values, flagValue{override: o, value: v})
+			return nil
+		})
+	}
+}
+
+func (f *flagSet) apply(cfg *Config) error {
+	var errs []error
+	for _, fv := range f.values {
+		if err := fv.apply(cfg, fv.value); err != nil {
+			errs = append(errs, fmt.Errorf("flag -%s: %w", fv.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"test/internal/logger"
+	"time"
+)
+
+// reloadable - поля, которые применяются без перезапуска. Поле подходит,
+// если его путь совпадает с элементом списка или начинается с него
+var reloadable = []string{
+	"cache_params.amount",
+	"log.level",
+	"consumer.poll_interval",
+	"consumer.commit_batch_size",
+	"consumer.commit_interval",
+}
+
+func isReloadable(field string) bool {
+	for _, prefix := range reloadable {
+		if field == prefix || strings.HasPrefix(field, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff возвращает yaml-пути полей, которые отличаются в old и new
+func Diff(old, new *Config) []string {
+	var fields []string
+	diffValues(reflect.ValueOf(*old), reflect.ValueOf(*new), "", &fields)
+	return fields
+}
+
+func diffValues(a, b reflect.Value, path string, fields *[]string) {
+	if a.Kind() != reflect.Struct {
+		if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+			*fields = append(*fields, path)
+		}
+		return
+	}
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if name == "" {
+			name = strings.ToLower(t.Field(i).Name)
+		}
+		if path != "" {
+			name = path + "." + name
+		}
+		diffValues(a.Field(i), b.Field(i), name, fields)
+	}
+}
+
+// Watcher перечитывает конфиг по SIGHUP и при изменении файла и передает
+// в apply конфиг, в котором изменены только поля из reloadable
+type Watcher struct {
+	log   *slog.Logger
+	apply func(cfg *Config)
+
+	mu      sync.Mutex
+	current *Config
+}
+
+func NewWatcher(log *slog.Logger, cfg *Config, apply func(cfg *Config)) *Watcher {
+	return &Watcher{
+		log:     log,
+		apply:   apply,
+		current: cfg,
+	}
+}
+
+// Run работает до отмены ctx. Интервал проверки файла берется из стартового конфига
+func (w *Watcher) Run(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	if interval := w.current.Reload.Interval; interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	lastMod := modTime(Path())
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			w.log.Info("SIGHUP received, reloading config")
+			lastMod = modTime(Path())
+			w.Reload()
+		case <-tick:
+			if mod := modTime(Path()); !mod.Equal(lastMod) {
+				lastMod = mod
+				w.log.Info("config file changed, reloading", slog.String("path", Path()))
+				w.Reload()
+			}
+		}
+	}
+}
+
+// Reload перечитывает конфиг. Если файл не проходит проверку, остается текущий конфиг,
+// изменения полей вне reloadable отклоняются с сообщением в лог
+func (w *Watcher) Reload() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	loaded, err := Load()
+	if err != nil {
+		w.log.Error("config reload failed, keeping current config", logger.Err(err))
+		return
+	}
+
+	var applied, rejected []string
+	for _, field := range Diff(w.current, loaded) {
+		if isReloadable(field) {
+			applied = append(applied, field)
+		} else {
+			rejected = append(rejected, field)
+		}
+	}
+	if len(rejected) > 0 {
+		w.log.Warn("config changes require a restart and are ignored", slog.Any("fields", rejected))
+	}
+	if len(applied) == 0 {
+		return
+	}
+
+	next := *w.current
+	next.CacheParams.Amount = loaded.CacheParams.Amount
+	next.Log.Level = loaded.Log.Level
+	next.Consumer.PollInterval = loaded.Consumer.PollInterval
+	next.Consumer.CommitBatchSize = loaded.Consumer.CommitBatchSize
+	next.Consumer.CommitInterval = loaded.Consumer.CommitInterval
+	w.current = &next
+	w.apply(&next)
+	w.log.Info("config reloaded", slog.Any("fields", applied))
+}
+
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
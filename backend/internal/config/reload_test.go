@@ -0,0 +1,112 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	old := validConfig()
+	changed := validConfig()
+	changed.CacheParams.Amount = 100
+	changed.Kafka.Brokers = []string{"k1:9092", "k2:9092"}
+	changed.Kafka.Topics.JSONData.Partitions = 6
+
+	want := []string{"kafka.brokers", "kafka.topics.json_data.partitions", "cache_params.amount"}
+	if got := Diff(old, changed); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %v, want %v", got, want)
+	}
+	if got := Diff(old, validConfig()); len(got) != 0 {
+		t.Errorf("Diff() of equal configs = %v", got)
+	}
+}
+
+func TestWatcherReload(t *testing.T) {
+	resetFlags(t)
+	path := writeConfig(t, testConfig)
+	t.Setenv("CONFIG_PATH", path)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var applied []*Config
+	w := NewWatcher(slog.New(slog.DiscardHandler), cfg, func(next *Config) {
+		applied = append(applied, next)
+	})
+
+	// Размер кэша и poll_interval применяются, адрес HTTP сервера - нет
+	err = os.WriteFile(path, []byte(testConfig+`
+consumer:
+  poll_interval: 1s
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("LOG_LEVEL", "debug")
+	w.Reload()
+	if len(applied) != 1 {
+		t.Fatalf("apply called %d times, want 1", len(applied))
+	}
+	if got := applied[0]; got.Consumer.PollInterval != time.Second || got.Log.Level != "debug" {
+		t.Errorf("reloadable fields not applied: %+v %+v", got.Consumer, got.Log)
+	}
+
+	t.Setenv("LOG_LEVEL", "info")
+	err = os.WriteFile(path, []byte(`
+http_server:
+  address: "localhost:9999"
+db_path:
+  host: "localhost"
+  port: "5432"
+  dbname: "service"
+  username: "postgres"
+  password: "secret"
+cache_params:
+  amount: 5
+  path: "cache.json"
+consumer:
+  poll_interval: 1s
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Reload()
+	if len(applied) != 2 {
+		t.Fatalf("apply called %d times, want 2", len(applied))
+	}
+	got := applied[1]
+	if got.CacheParams.Amount != 5 || got.Log.Level != "info" {
+		t.Errorf("reloadable fields not applied: amount=%d level=%s", got.CacheParams.Amount, got.Log.Level)
+	}
+	if got.HTTPServer.Address != "localhost:8081" {
+		t.Errorf("http_server.address changed to %s without restart", got.HTTPServer.Address)
+	}
+
+	// Невалидный конфиг не применяется
+	if err := os.WriteFile(path, []byte("cache_params:\n  amount: 0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	w.Reload()
+	if len(applied) != 2 {
+		t.Errorf("invalid config was applied")
+	}
+}
+
+func TestIsReloadable(t *testing.T) {
+	for field, want := range map[string]bool{
+		"cache_params.amount":           true,
+		"log.level":                     true,
+		"consumer.commit_interval":      true,
+		"consumer.order_id.concurrency": false,
+		"cache_params.policy":           false,
+		"kafka.brokers":                 false,
+	} {
+		if got := isReloadable(field); got != want {
+			t.Errorf("isReloadable(%s) = %v, want %v", field, got, want)
+		}
+	}
+}
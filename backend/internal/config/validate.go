@@ -0,0 +1,173 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+)
+
+// Значения должны совпадать с политиками пакета cache, уровнями/форматами пакета logger
+// и порядком обработки консьюмеров пакета broker
+var (
+	cachePolicies = map[string]bool{"fifo": true, "lru": true, "lfu": true}
+	cacheModes    = map[string]bool{"keys": true, "snapshot": true, "journal": true}
+	warmUpModes   = map[string]bool{"none": true, "date_created": true, "created_at": true}
+	logLevels     = map[string]bool{"debug": true, "info": true, "warn": true, "error": true}
+	logFormats    = map[string]bool{"text": true, "json": true}
+	orderModes    = map[string]bool{"partition": true, "key": true}
+	erasureModes  = map[string]bool{"delete": true, "anonymize": true}
+)
+
+type checkFunc func(ok bool, field, format string, args ...any)
+
+// checker собирает ошибки проверок, errors.Join возвращает их все сразу
+func checker() (checkFunc, *[]error) {
+	var errs []error
+	return func(ok bool, field, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+		}
+	}, &errs
+}
+
+// ValidateDatabase проверяет только подключение к БД и логи
+func (c *Config) ValidateDatabase() error {
+	check, errs := checker()
+	c.checkDatabase(check)
+	c.checkLog(check)
+	return errors.Join(*errs...)
+}
+
+func (c *Config) checkDatabase(check checkFunc) {
+	db := c.PostgresConnection
+	check(db.Host != "", "db_path.host", "is required")
+	port, err := strconv.Atoi(db.Port)
+	check(err == nil && port > 0 && port <= 65535, "db_path.port", "must be a port number, got %q", db.Port)
+	check(db.DBName != "", "db_path.dbname", "is required (DB_NAME)")
+	check(db.Username != "", "db_path.username", "is required (DB_USER)")
+	check(db.Password != "", "db_path.password", "is required (DB_PASSWORD)")
+}
+
+func (c *Config) checkLog(check checkFunc) {
+	check(logLevels[c.Log.Level], "log.level", "must be debug, info, warn or error, got %q", c.Log.Level)
+	check(logFormats[c.Log.Format], "log.format", "must be text or json, got %q", c.Log.Format)
+}
+
+// Validate проверяет диапазоны и сочетания значений и возвращает все найденные ошибки
+func (c *Config) Validate() error {
+	check, errs := checker()
+
+	// HTTP сервер
+	check(c.HTTPServer.Address != "", "http_server.address", "is required")
+	check(c.HTTPServer.Timeout > 0, "http_server.timeout", "must be positive, got %s", c.HTTPServer.Timeout)
+	check(c.HTTPServer.IdleTimeout > 0, "http_server.idle_timeout", "must be positive, got %s", c.HTTPServer.IdleTimeout)
+
+	// Postgres
+	c.checkDatabase(check)
+
+	// Kafka
+	k := c.Kafka
+	check(len(k.Brokers) > 0, "kafka.brokers", "at least one broker is required")
+	for i, addr := range k.Brokers {
+		_, _, err := net.SplitHostPort(addr)
+		check(err == nil, fmt.Sprintf("kafka.brokers[%d]", i), "must be host:port, got %q", addr)
+	}
+	check(k.ClientID != "", "kafka.client_id", "is required")
+	check(k.MinBytes > 0, "kafka.min_bytes", "must be positive, got %d", k.MinBytes)
+	check(k.MaxBytes >= k.MinBytes, "kafka.max_bytes", "must not be less than min_bytes (%d), got %d", k.MinBytes, k.MaxBytes)
+
+	topics := []struct {
+		field  string
+		params TopicParams
+	}{
+		{"kafka.topics.order_id", k.Topics.OrderID},
+		{"kafka.topics.json_data", k.Topics.JSONData},
+		{"kafka.topics.order_response", k.Topics.OrderResponse},
+		{"kafka.topics.order_status", k.Topics.OrderStatus},
+		{"kafka.topics.order_erasure", k.Topics.OrderErasure},
+		{"kafka.topics.dead_letter", k.Topics.DeadLetter},
+	}
+	names := make(map[string]string, len(topics))
+	for _, t := range topics {
+		check(t.params.Name != "", t.field+".name", "is required")
+		if other, ok := names[t.params.Name]; ok && t.params.Name != "" {
+			check(false, t.field+".name", "%q is already used by %s", t.params.Name, other)
+		}
+		names[t.params.Name] = t.field
+		check(t.params.Partitions > 0, t.field+".partitions", "must be positive, got %d", t.params.Partitions)
+		check(t.params.Replication > 0, t.field+".replication", "must be positive, got %d", t.params.Replication)
+		check(t.params.Retention >= 0, t.field+".retention", "must not be negative, got %s", t.params.Retention)
+	}
+	// Ответ в читаемый сервисом топик был бы прочитан как новое сообщение
+	for i, topic := range k.ReplyTopics {
+		field := fmt.Sprintf("kafka.reply_topics[%d]", i)
+		check(topic != "", field, "must not be empty")
+		if other, ok := names[topic]; ok && topic != k.Topics.OrderResponse.Name {
+			check(false, field, "%q is already used by %s", topic, other)
+		}
+	}
+	check(k.Groups.OrderID != "", "kafka.groups.order_id", "is required")
+	check(k.Groups.JSONData != "", "kafka.groups.json_data", "is required")
+	check(k.Groups.OrderStatus != "", "kafka.groups.order_status", "is required")
+	check(k.Groups.OrderErasure != "", "kafka.groups.order_erasure", "is required")
+	groups := map[string]bool{}
+	for _, group := range []string{k.Groups.OrderID, k.Groups.JSONData, k.Groups.OrderStatus, k.Groups.OrderErasure} {
+		groups[group] = true
+	}
+	check(len(groups) == 4, "kafka.groups", "order_id, json_data, order_status and order_erasure must use different groups")
+
+	// Кэш
+	check(c.CacheParams.Amount > 0, "cache_params.amount", "must be positive, got %d", c.CacheParams.Amount)
+	check(cachePolicies[c.CacheParams.Policy], "cache_params.policy", "must be fifo, lru or lfu, got %q", c.CacheParams.Policy)
+	check(cacheModes[c.CacheParams.Mode], "cache_params.mode", "must be keys, snapshot or journal, got %q", c.CacheParams.Mode)
+	check(c.CacheParams.JournalCompactEvery > 0, "cache_params.journal_compact_every", "must be positive, got %d", c.CacheParams.JournalCompactEvery)
+	check(!c.CacheParams.Revalidate || c.CacheParams.Mode != "keys", "cache_params.revalidate",
+		"is only supported with mode snapshot or journal")
+	check(warmUpModes[c.CacheParams.WarmUp], "cache_params.warm_up", "must be none, date_created or created_at, got %q", c.CacheParams.WarmUp)
+	check(c.CacheParams.WarmUpLimit >= 0, "cache_params.warm_up_limit", "must not be negative, got %d", c.CacheParams.WarmUpLimit)
+	check(c.CacheParams.Path != "", "cache_params.path", "is required (CACHE_PATH)")
+
+	// Логи, остановка и проверки состояния
+	c.checkLog(check)
+	check(c.ShutdownTimeout > 0, "shutdown_timeout", "must be positive, got %s", c.ShutdownTimeout)
+	check(c.Health.Timeout > 0, "health.timeout", "must be positive, got %s", c.Health.Timeout)
+	check(c.Health.HeartbeatTimeout > c.Health.Timeout, "health.heartbeat_timeout",
+		"must be greater than health.timeout (%s), got %s", c.Health.Timeout, c.Health.HeartbeatTimeout)
+
+	// Консьюмер без сообщений должен успевать отмечать heartbeat
+	check(c.Consumer.PollInterval > 0, "consumer.poll_interval", "must be positive, got %s", c.Consumer.PollInterval)
+	check(c.Consumer.PollInterval < c.Health.HeartbeatTimeout, "consumer.poll_interval",
+		"must be less than health.heartbeat_timeout (%s), got %s", c.Health.HeartbeatTimeout, c.Consumer.PollInterval)
+	check(c.Consumer.CommitBatchSize > 0, "consumer.commit_batch_size", "must be positive, got %d", c.Consumer.CommitBatchSize)
+	check(c.Consumer.CommitInterval > 0, "consumer.commit_interval", "must be positive, got %s", c.Consumer.CommitInterval)
+	for _, w := range []struct {
+		field  string
+		params WorkerParams
+	}{
+		{"consumer.order_id", c.Consumer.OrderID},
+		{"consumer.json_data", c.Consumer.JSONData},
+		{"consumer.order_status", c.Consumer.OrderStatus},
+		{"consumer.order_erasure", c.Consumer.OrderErasure},
+	} {
+		check(w.params.Concurrency > 0, w.field+".concurrency", "must be positive, got %d", w.params.Concurrency)
+		check(w.params.QueueSize > 0, w.field+".queue_size", "must be positive, got %d", w.params.QueueSize)
+		check(orderModes[w.params.OrderBy], w.field+".order_by", "must be partition or key, got %q", w.params.OrderBy)
+	}
+	check(c.Reload.Interval >= 0, "reload.interval", "must not be negative, got %s", c.Reload.Interval)
+
+	check(erasureModes[c.Erasure.Mode], "erasure.mode", "must be delete or anonymize, got %q", c.Erasure.Mode)
+
+	// Машина состояний статусов
+	check(len(c.OrderStatus.Transitions) > 0, "order_status.transitions", "at least one transition is required")
+	for from, targets := range c.OrderStatus.Transitions {
+		field := fmt.Sprintf("order_status.transitions[%d]", from)
+		check(from > 0, field, "status must be positive")
+		for _, to := range targets {
+			check(to > 0, field, "status must be positive, got %d", to)
+			check(to != from, field, "transition to the same status")
+		}
+	}
+
+	return errors.Join(*errs...)
+}
@@ -70,7 +70,7 @@ func TestUnmarshalingOrderDataMessages(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := UnmarshalingOrderDataMessages(nil, tt.args.message); err != tt.wantErr {
+			if _, err := UnmarshalingOrderDataMessages(tt.args.message); err != tt.wantErr {
 				t.Errorf("UnmarshalingOrderDataMessages() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -0,0 +1,321 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"test/internal/health"
+	"test/internal/logger"
+	"test/internal/metrics"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// MessageReader - часть kafka.Reader, необходимая консьюмеру. Оффсеты коммитятся
+// вручную после обработки, поэтому ReaderConfig.CommitInterval должен быть 0
+type MessageReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// Handler обрабатывает одно сообщение топика
+type Handler interface {
+	Handle(ctx context.Context, msg kafka.Message) error
+}
+
+// Порядок обработки: сообщения с одинаковой партицией или ключом попадают
+// к одному обработчику и обрабатываются последовательно
+const (
+	OrderByPartition = "partition"
+	OrderByKey       = "key"
+)
+
+const (
+	// Как часто консьюмер без сообщений отмечает heartbeat, если не задано SetPollInterval
+	defaultPollInterval = 5 * time.Second
+	// Пачка коммита по умолчанию, если не задано SetCommitBatch
+	defaultCommitBatchSize = 100
+	defaultCommitInterval  = time.Second
+	// Пауза перед повторной обработкой сообщения растет от minRetryBackoff до maxRetryBackoff
+	minRetryBackoff = 100 * time.Millisecond
+	maxRetryBackoff = 10 * time.Second
+)
+
+// Consumer читает сообщения и раздает их пулу обработчиков, пока не отменен контекст.
+// Оффсет коммитится только после успешной обработки (at-least-once): сообщение,
+// на котором сервис остановился или упал, будет прочитано снова
+type Consumer struct {
+	log       *slog.Logger
+	topic     string
+	reader    MessageReader
+	handler   Handler
+	heartbeat *health.Heartbeat
+	// Меняются при перезагрузке конфига, поэтому хранятся атомарно
+	pollInterval    atomic.Int64
+	commitBatchSize atomic.Int64
+	commitInterval  atomic.Int64
+
+	// Пул обработчиков, задается до Run
+	concurrency int
+	queueSize   int
+	orderBy     string
+
+	offsets  *offsetTracker
+	commitMu sync.Mutex
+}
+
+// heartbeat может быть nil. По умолчанию сообщения обрабатываются одним обработчиком
+func NewConsumer(log *slog.Logger, topic string, reader MessageReader, handler Handler, heartbeat *health.Heartbeat) *Consumer {
+	c := &Consumer{
+		log:         log,
+		topic:       topic,
+		reader:      reader,
+		handler:     handler,
+		heartbeat:   heartbeat,
+		concurrency: 1,
+		queueSize:   1,
+		orderBy:     OrderByPartition,
+		offsets:     newOffsetTracker(),
+	}
+	c.pollInterval.Store(int64(defaultPollInterval))
+	c.commitBatchSize.Store(defaultCommitBatchSize)
+	c.commitInterval.Store(int64(defaultCommitInterval))
+	return c
+}
+
+// SetWorkers задает число обработчиков, длину очереди каждого и порядок обработки.
+// Вызывается до Run. Когда очередь обработчика заполнена, чтение ждет (backpressure).
+// При OrderByPartition параллельно обрабатывается не больше партиций, чем есть у топика
+func (c *Consumer) SetWorkers(concurrency, queueSize int, orderBy string) {
+	c.concurrency = max(concurrency, 1)
+	c.queueSize = max(queueSize, 1)
+	c.orderBy = orderBy
+}
+
+// SetPollInterval задает таймаут одного чтения, безопасен во время Run
+func (c *Consumer) SetPollInterval(d time.Duration) {
+	if d > 0 {
+		c.pollInterval.Store(int64(d))
+	}
+}
+
+// SetCommitBatch задает размер пачки и максимальную задержку коммита, безопасен во время Run
+func (c *Consumer) SetCommitBatch(size int, interval time.Duration) {
+	if size > 0 {
+		c.commitBatchSize.Store(int64(size))
+	}
+	if interval > 0 {
+		c.commitInterval.Store(int64(interval))
+	}
+}
+
+// Run возвращает nil после отмены ctx. Начатые сообщения обрабатываются до конца
+// с контекстом без отмены, чтобы не прерывать транзакцию на середине; сообщения,
+// которые еще ждут в очередях, не обрабатываются и будут прочитаны снова.
+// Перед выходом коммитятся оффсеты обработанных сообщений
+func (c *Consumer) Run(ctx context.Context) error {
+	const op = "handlers.broker.Consumer.Run"
+
+	c.log.Info("consumer started", slog.String("topic", c.topic),
+		slog.Int("concurrency", c.concurrency), slog.String("order_by", c.orderBy))
+	defer c.heartbeat.Stop()
+
+	queues := make([]chan kafka.Message, c.concurrency)
+	var workers sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan kafka.Message, c.queueSize)
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			c.work(ctx, queues[i])
+		}()
+	}
+	defer func() {
+		for _, queue := range queues {
+			close(queue)
+		}
+		workers.Wait()
+		c.commit(context.WithoutCancel(ctx))
+	}()
+
+	for {
+		c.heartbeat.Beat()
+		c.maybeCommit(ctx)
+
+		// Чтение ограничено pollInterval, чтобы heartbeat обновлялся и без сообщений,
+		// и сроком коммита, чтобы обработанные сообщения не ждали следующего
+		readCtx, cancel := context.WithTimeout(ctx, c.readTimeout())
+		msg, err := c.reader.FetchMessage(readCtx)
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+				return nil
+			}
+			if errors.Is(err, context.DeadlineExceeded) {
+				continue
+			}
+			return fmt.Errorf("%s: read %s: %w", op, c.topic, err)
+		}
+		metrics.MessagesConsumed.WithLabelValues(c.topic).Inc()
+
+		c.offsets.start(msg)
+		if !c.dispatch(ctx, queues[c.worker(msg)], msg) {
+			return nil
+		}
+	}
+}
+
+// worker выбирает обработчика: одинаковые ключ порядка - один обработчик
+func (c *Consumer) worker(msg kafka.Message) int {
+	if c.orderBy == OrderByKey && len(msg.Key) > 0 {
+		h := fnv.New32a()
+		h.Write(msg.Key)
+		return int(h.Sum32() % uint32(c.concurrency))
+	}
+	return msg.Partition % c.concurrency
+}
+
+// dispatch ждет места в очереди обработчика, продолжая отмечать heartbeat
+// и коммитить уже обработанное. Возвращает false, если ctx отменен раньше
+func (c *Consumer) dispatch(ctx context.Context, queue chan<- kafka.Message, msg kafka.Message) bool {
+	for {
+		select {
+		case queue <- msg:
+			metrics.ConsumerQueueLength.WithLabelValues(c.topic).Inc()
+			return true
+		case <-ctx.Done():
+			return false
+		case <-time.After(c.readTimeout()):
+			c.heartbeat.Beat()
+			c.maybeCommit(ctx)
+		}
+	}
+}
+
+func (c *Consumer) work(ctx context.Context, queue <-chan kafka.Message) {
+	for msg := range queue {
+		metrics.ConsumerQueueLength.WithLabelValues(c.topic).Dec()
+		// После остановки сообщения из очереди пропускаются без коммита
+		if ctx.Err() != nil {
+			continue
+		}
+		if c.handle(ctx, msg) {
+			c.offsets.done(msg)
+			// Полная пачка коммитится сразу, не дожидаясь следующего чтения
+			c.maybeCommit(ctx)
+		}
+	}
+}
+
+// readTimeout не дает обработанным сообщениям ждать коммита дольше commitInterval
+func (c *Consumer) readTimeout() time.Duration {
+	interval := time.Duration(c.commitInterval.Load())
+	timeout := min(time.Duration(c.pollInterval.Load()), interval)
+	if _, ready, firstReady := c.offsets.pending(); ready > 0 {
+		timeout = min(timeout, max(interval-time.Since(firstReady), time.Millisecond))
+	}
+	return timeout
+}
+
+// handle повторяет обработку с растущей паузой, пока она не пройдет успешно.
+// Сообщение нельзя пропустить: коммит следующих оффсетов подтвердил бы и его.
+// Возвращает false, если ctx отменен раньше
+func (c *Consumer) handle(ctx context.Context, msg kafka.Message) bool {
+	const op = "handlers.broker.Consumer.handle"
+
+	backoff := minRetryBackoff
+	for {
+		err := c.handler.Handle(context.WithoutCancel(ctx), msg)
+		if err == nil {
+			return true
+		}
+		messageLogger(c.log, msg).Error("failed to handle message, retrying",
+			slog.String("op", op), slog.Duration("backoff", backoff), logger.Err(err))
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(backoff):
+		}
+		c.heartbeat.Beat()
+		backoff = min(backoff*2, maxRetryBackoff)
+	}
+}
+
+// maybeCommit коммитит, когда набралась пачка или истек интервал коммита
+func (c *Consumer) maybeCommit(ctx context.Context) {
+	_, ready, firstReady := c.offsets.pending()
+	if ready >= int(c.commitBatchSize.Load()) ||
+		(ready > 0 && time.Since(firstReady) >= time.Duration(c.commitInterval.Load())) {
+		c.commit(ctx)
+	}
+}
+
+// commit подтверждает обработанные префиксы партиций. При ошибке они остаются
+// и коммитятся следующей пачкой, в худшем случае будут прочитаны повторно
+func (c *Consumer) commit(ctx context.Context) {
+	const op = "handlers.broker.Consumer.commit"
+
+	// Коммит вызывают и цикл чтения, и обработчики
+	c.commitMu.Lock()
+	defer c.commitMu.Unlock()
+
+	msgs, ready, _ := c.offsets.pending()
+	if ready == 0 {
+		return
+	}
+	if err := c.reader.CommitMessages(ctx, msgs...); err != nil {
+		metrics.OffsetCommits.WithLabelValues(c.topic, "error").Inc()
+		c.log.Warn("failed to commit offsets", slog.String("op", op), slog.String("topic", c.topic),
+			slog.Int("messages", ready), logger.Err(err))
+		c.offsets.retryLater()
+		return
+	}
+	metrics.OffsetCommits.WithLabelValues(c.topic, "ok").Inc()
+	c.offsets.committed(msgs, ready)
+	c.log.Debug("offsets committed", slog.String("topic", c.topic), slog.Int("messages", ready))
+}
+
+// messageLogger добавляет к записям координаты сообщения в kafka
+func messageLogger(log *slog.Logger, msg kafka.Message) *slog.Logger {
+	return log.With(
+		slog.String("topic", msg.Topic),
+		slog.Int("partition", msg.Partition),
+		slog.Int64("offset", msg.Offset),
+	)
+}
+
+// CheckTopics проверяет, что кластер доступен и в метаданных есть все топики
+func CheckTopics(ctx context.Context, brokers []string, topics ...string) error {
+	const op = "handlers.broker.CheckTopics"
+
+	conn, err := Dial(ctx, brokers)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	partitions, err := conn.ReadPartitions(topics...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	found := make(map[string]bool, len(topics))
+	for _, p := range partitions {
+		found[p.Topic] = true
+	}
+	for _, topic := range topics {
+		if !found[topic] {
+			return fmt.Errorf("%s: topic %s not found", op, topic)
+		}
+	}
+	return nil
+}
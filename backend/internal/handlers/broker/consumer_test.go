@@ -0,0 +1,257 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"reflect"
+	"sync"
+	"test/internal/health"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// readerStub отдает сообщения из канала и блокируется, как kafka.Reader, когда их нет
+type readerStub struct {
+	msgs chan kafka.Message
+
+	mu        sync.Mutex
+	committed []kafka.Message
+	commits   int
+}
+
+func (r *readerStub) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	select {
+	case msg := <-r.msgs:
+		return msg, nil
+	case <-ctx.Done():
+		return kafka.Message{}, ctx.Err()
+	}
+}
+
+func (r *readerStub) CommitMessages(_ context.Context, msgs ...kafka.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.committed = append(r.committed, msgs...)
+	r.commits++
+	return nil
+}
+
+// offsets возвращает закоммиченные оффсеты по партициям
+func (r *readerStub) offsets() map[int]int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	offsets := make(map[int]int64)
+	for _, msg := range r.committed {
+		offsets[msg.Partition] = max(offsets[msg.Partition], msg.Offset)
+	}
+	return offsets
+}
+
+type handlerFunc func(ctx context.Context, msg kafka.Message) error
+
+func (f handlerFunc) Handle(ctx context.Context, msg kafka.Message) error {
+	return f(ctx, msg)
+}
+
+func TestConsumer_RunStopsOnCancel(t *testing.T) {
+	reader := &readerStub{msgs: make(chan kafka.Message, 1)}
+	handled := make(chan context.Context, 1)
+	heartbeat := &health.Heartbeat{}
+	consumer := NewConsumer(slog.New(slog.DiscardHandler), "json_data", reader, handlerFunc(func(ctx context.Context, msg kafka.Message) error {
+		handled <- ctx
+		return nil
+	}), heartbeat)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- consumer.Run(ctx) }()
+
+	reader.msgs <- kafka.Message{Value: []byte("b563feb7b2b84b6test")}
+	handlerCtx := <-handled
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Run() did not stop after cancel")
+	}
+	// Контекст обработки не отменяется вместе с корневым
+	if handlerCtx.Err() != nil {
+		t.Errorf("handler context cancelled: %v", handlerCtx.Err())
+	}
+	// После остановки heartbeat снят
+	if err := heartbeat.Check(time.Minute)(context.Background()); err == nil {
+		t.Error("heartbeat check passed after consumer stopped")
+	}
+}
+
+func TestConsumer_CommitsAfterHandling(t *testing.T) {
+	reader := &readerStub{msgs: make(chan kafka.Message, 5)}
+	var handled []int64
+	consumer := NewConsumer(slog.New(slog.DiscardHandler), "json_data", reader, handlerFunc(func(ctx context.Context, msg kafka.Message) error {
+		handled = append(handled, msg.Offset)
+		return nil
+	}), nil)
+	consumer.SetCommitBatch(2, time.Hour)
+
+	for i, partition := range []int{0, 1, 0, 1, 0} {
+		reader.msgs <- kafka.Message{Partition: partition, Offset: int64(i)}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- consumer.Run(ctx) }()
+
+	// Две полные пачки коммитятся сразу, пятое сообщение - при остановке
+	deadline := time.Now().Add(time.Second)
+	for {
+		reader.mu.Lock()
+		commits := reader.commits
+		reader.mu.Unlock()
+		if commits == 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got, want := reader.offsets(), map[int]int64{0: 2, 1: 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("committed before shutdown = %v, want %v", got, want)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got, want := reader.offsets(), map[int]int64{0: 4, 1: 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("committed after shutdown = %v, want %v", got, want)
+	}
+	if len(handled) != 5 {
+		t.Errorf("handled %d messages, want 5", len(handled))
+	}
+}
+
+func TestConsumer_CommitInterval(t *testing.T) {
+	reader := &readerStub{msgs: make(chan kafka.Message, 1)}
+	consumer := NewConsumer(slog.New(slog.DiscardHandler), "json_data", reader, handlerFunc(func(ctx context.Context, msg kafka.Message) error {
+		return nil
+	}), nil)
+	consumer.SetCommitBatch(100, 20*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go consumer.Run(ctx)
+	reader.msgs <- kafka.Message{Offset: 7}
+
+	// Неполная пачка коммитится по интервалу, не дожидаясь poll_interval
+	deadline := time.Now().Add(time.Second)
+	for reader.offsets()[0] != 7 {
+		if time.Now().After(deadline) {
+			t.Fatal("offset was not committed after commit interval")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestConsumer_RetriesFailedMessage(t *testing.T) {
+	reader := &readerStub{msgs: make(chan kafka.Message, 1)}
+	attempts := make(chan int, 10)
+	n := 0
+	consumer := NewConsumer(slog.New(slog.DiscardHandler), "json_data", reader, handlerFunc(func(ctx context.Context, msg kafka.Message) error {
+		n++
+		attempts <- n
+		return errors.New("dead-letter topic is unavailable")
+	}), nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- consumer.Run(ctx) }()
+	reader.msgs <- kafka.Message{Offset: 3}
+
+	// Сообщение обрабатывается повторно, а не пропускается
+	<-attempts
+	<-attempts
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got := reader.offsets(); len(got) != 0 {
+		t.Errorf("failed message was committed: %v", got)
+	}
+}
+
+func TestConsumer_WorkersKeepKeyOrder(t *testing.T) {
+	reader := &readerStub{msgs: make(chan kafka.Message, 20)}
+	var mu sync.Mutex
+	seen := make(map[string][]int64)
+	release := make(chan struct{})
+	consumer := NewConsumer(slog.New(slog.DiscardHandler), "order_id", reader, handlerFunc(func(ctx context.Context, msg kafka.Message) error {
+		// Ключ slow блокирует свой обработчик, остальные ключи идут параллельно
+		if string(msg.Key) == "slow" {
+			<-release
+		}
+		mu.Lock()
+		seen[string(msg.Key)] = append(seen[string(msg.Key)], msg.Offset)
+		mu.Unlock()
+		return nil
+	}), nil)
+	consumer.SetWorkers(4, 8, OrderByKey)
+	consumer.SetCommitBatch(100, 10*time.Millisecond)
+
+	// Ключи a и b выбираются так, чтобы они попали не к обработчику slow
+	workerOf := func(key string) int { return consumer.worker(kafka.Message{Key: []byte(key)}) }
+	var free []string
+	for c := 'a'; len(free) < 2; c++ {
+		if key := string(c); workerOf(key) != workerOf("slow") {
+			free = append(free, key)
+		}
+	}
+	a, b := free[0], free[1]
+	keys := []string{"slow", a, b, a, b, a}
+	for i, key := range keys {
+		reader.msgs <- kafka.Message{Partition: 0, Offset: int64(i), Key: []byte(key)}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- consumer.Run(ctx) }()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		n := len(seen[a]) + len(seen[b])
+		mu.Unlock()
+		if n == 5 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("messages with other keys are blocked by a slow key")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// Пока offset 0 не обработан, коммитить нечего
+	if got := reader.offsets(); len(got) != 0 {
+		t.Errorf("committed %v before the first message was handled", got)
+	}
+
+	close(release)
+	deadline = time.Now().Add(time.Second)
+	for reader.offsets()[0] != 5 {
+		if time.Now().After(deadline) {
+			t.Fatalf("committed = %v, want offset 5", reader.offsets())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if want := []int64{1, 3, 5}; !reflect.DeepEqual(seen[a], want) {
+		t.Errorf("key %s handled in order %v, want %v", a, seen[a], want)
+	}
+	if want := []int64{2, 4}; !reflect.DeepEqual(seen[b], want) {
+		t.Errorf("key %s handled in order %v, want %v", b, seen[b], want)
+	}
+}
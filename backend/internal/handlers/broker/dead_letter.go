@@ -0,0 +1,80 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"test/internal/logger"
+	"test/internal/metrics"
+	"test/internal/validation"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Заголовки сообщений в dead-letter топике
+const (
+	HeaderDLQTopic     = "dlq_original_topic"
+	HeaderDLQPartition = "dlq_original_partition"
+	HeaderDLQOffset    = "dlq_original_offset"
+	HeaderDLQError     = "dlq_error"
+	HeaderDLQStage     = "dlq_stage"
+	// Список ошибок полей в JSON, если сообщение не прошло валидацию
+	HeaderDLQFieldErrors = "dlq_field_errors"
+)
+
+// Этапы обработки, на которых сообщение может попасть в dead-letter топик
+const (
+	StageDecode   = "decode"
+	StageValidate = "validate"
+	StageStore    = "store"
+)
+
+// NewDeadLetter собирает сообщение для dead-letter топика: исходные ключ, тело и заголовки
+// сохраняются, к ним добавляются сведения об источнике и причине ошибки
+func NewDeadLetter(msg kafka.Message, topic, stage string, cause error) kafka.Message {
+	headers := make([]kafka.Header, 0, len(msg.Headers)+6)
+	headers = append(headers, msg.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: HeaderDLQTopic, Value: []byte(msg.Topic)},
+		kafka.Header{Key: HeaderDLQPartition, Value: []byte(strconv.Itoa(msg.Partition))},
+		kafka.Header{Key: HeaderDLQOffset, Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+		kafka.Header{Key: HeaderDLQError, Value: []byte(cause.Error())},
+		kafka.Header{Key: HeaderDLQStage, Value: []byte(stage)},
+	)
+
+	var fieldErrs validation.Errors
+	if errors.As(cause, &fieldErrs) {
+		if value, err := json.Marshal(fieldErrs); err == nil {
+			headers = append(headers, kafka.Header{Key: HeaderDLQFieldErrors, Value: value})
+		}
+	}
+
+	return kafka.Message{
+		Topic:   topic,
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	}
+}
+
+// writeDeadLetter отправляет сообщение в dead-letter топик. Ошибка возвращается, только
+// если отправить не удалось: тогда сообщение не коммитится и будет обработано снова
+func writeDeadLetter(ctx context.Context, log *slog.Logger, writer MessageWriter, dlqTopic string, msg kafka.Message, stage string, cause error) error {
+	const op = "handlers.broker.writeDeadLetter"
+
+	log.Warn("message sent to dead-letter topic",
+		slog.String("op", op), slog.String("stage", stage), slog.String("dlq_topic", dlqTopic), logger.Err(cause))
+	metrics.MessageFailures.WithLabelValues(msg.Topic, stage).Inc()
+
+	start := time.Now()
+	err := writer.WriteMessages(ctx, NewDeadLetter(msg, dlqTopic, stage, cause))
+	metrics.ObserveWrite(dlqTopic, start, err)
+	if err != nil {
+		return fmt.Errorf("%s: write to %s: %w (original error: %v)", op, dlqTopic, err, cause)
+	}
+	return nil
+}
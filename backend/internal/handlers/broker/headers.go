@@ -0,0 +1,47 @@
+package broker
+
+import "github.com/segmentio/kafka-go"
+
+// Соглашение о заголовках для связи запросов order_id с ответами order_response:
+// клиент кладет в запрос correlation_id и, при необходимости, reply_to,
+// сервис копирует все заголовки запроса в ответ.
+const (
+	HeaderCorrelationID = "correlation_id"
+	HeaderReplyTo       = "reply_to"
+)
+
+// HeaderValue возвращает значение заголовка сообщения или пустую строку
+func HeaderValue(msg kafka.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// NewReply собирает ответ на запрос: ключ и заголовки копируются из запроса,
+// топик берется из reply_to, если он есть в allowed, иначе используется defaultTopic.
+// Второе значение false, если reply_to задан, но не разрешен. Ответ никогда не уходит
+// в топик самого запроса, иначе он был бы прочитан как новый запрос
+func NewReply(request kafka.Message, value []byte, defaultTopic string, allowed map[string]bool) (kafka.Message, bool) {
+	topic, ok := HeaderValue(request, HeaderReplyTo), true
+	if topic != "" && (!allowed[topic] || topic == request.Topic) {
+		topic, ok = "", false
+	}
+	if topic == "" {
+		topic = defaultTopic
+	}
+
+	headers := make([]kafka.Header, 0, len(request.Headers))
+	for _, h := range request.Headers {
+		headers = append(headers, kafka.Header{Key: h.Key, Value: append([]byte(nil), h.Value...)})
+	}
+
+	return kafka.Message{
+		Topic:   topic,
+		Key:     request.Key,
+		Value:   value,
+		Headers: headers,
+	}, ok
+}
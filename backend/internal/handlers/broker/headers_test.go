@@ -0,0 +1,80 @@
+package broker
+
+import (
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestNewReply(t *testing.T) {
+	allowed := map[string]bool{"order_response": true, "order_response.client1": true, "order_id": true}
+	tests := []struct {
+		name        string
+		request     kafka.Message
+		wantTopic   string
+		wantAllowed bool
+	}{
+		{
+			name: "reply_to header overrides topic",
+			request: kafka.Message{
+				Key:   []byte("1700000000"),
+				Value: []byte("b563feb7b2b84b6test"),
+				Headers: []kafka.Header{
+					{Key: HeaderCorrelationID, Value: []byte("abc")},
+					{Key: HeaderReplyTo, Value: []byte("order_response.client1")},
+				},
+			},
+			wantTopic:   "order_response.client1",
+			wantAllowed: true,
+		},
+		{
+			name: "unknown reply_to falls back to default topic",
+			request: kafka.Message{
+				Headers: []kafka.Header{
+					{Key: HeaderCorrelationID, Value: []byte("abc")},
+					{Key: HeaderReplyTo, Value: []byte("no-such-topic")},
+				},
+			},
+			wantTopic: "order_response",
+		},
+		{
+			name: "reply_to the request topic is rejected",
+			request: kafka.Message{
+				Topic: "order_id",
+				Headers: []kafka.Header{
+					{Key: HeaderCorrelationID, Value: []byte("abc")},
+					{Key: HeaderReplyTo, Value: []byte("order_id")},
+				},
+			},
+			wantTopic: "order_response",
+		},
+		{
+			name: "default topic without reply_to",
+			request: kafka.Message{
+				Key:     []byte("1700000000"),
+				Value:   []byte("b563feb7b2b84b6test"),
+				Headers: []kafka.Header{{Key: HeaderCorrelationID, Value: []byte("abc")}},
+			},
+			wantTopic:   "order_response",
+			wantAllowed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reply, ok := NewReply(tt.request, []byte(`{}`), "order_response", allowed)
+
+			if reply.Topic != tt.wantTopic || ok != tt.wantAllowed {
+				t.Errorf("Topic = %q, %v; want %q, %v", reply.Topic, ok, tt.wantTopic, tt.wantAllowed)
+			}
+			if string(reply.Key) != string(tt.request.Key) {
+				t.Errorf("Key = %q, want %q", reply.Key, tt.request.Key)
+			}
+			if got := HeaderValue(reply, HeaderCorrelationID); got != "abc" {
+				t.Errorf("correlation_id = %q, want %q", got, "abc")
+			}
+			if len(reply.Headers) != len(tt.request.Headers) {
+				t.Errorf("got %d headers, want %d", len(reply.Headers), len(tt.request.Headers))
+			}
+		})
+	}
+}
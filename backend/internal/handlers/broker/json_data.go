@@ -0,0 +1,70 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"test/internal/models"
+	"test/internal/orders"
+	"test/internal/validation"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// OrderSaver - получатель разобранных заказов из json_data
+type OrderSaver interface {
+	SaveOrder(ctx context.Context, order *models.Order) error
+}
+
+// JSONDataHandler сохраняет заказы из json_data, а сообщения, которые не удалось
+// разобрать, провалидировать или сохранить, отправляет в dead-letter топик
+type JSONDataHandler struct {
+	log      *slog.Logger
+	saver    OrderSaver
+	writer   MessageWriter
+	dlqTopic string
+}
+
+func NewJSONDataHandler(log *slog.Logger, saver OrderSaver, writer MessageWriter, dlqTopic string) *JSONDataHandler {
+	return &JSONDataHandler{
+		log:      log,
+		saver:    saver,
+		writer:   writer,
+		dlqTopic: dlqTopic,
+	}
+}
+
+// Handle возвращает ошибку, если заказ не удалось сохранить из-за временной ошибки
+// (например, БД недоступна) или сообщение не удалось отправить в dead-letter топик:
+// консьюмер повторит обработку и не закоммитит оффсет. В dead-letter топик уходят только
+// сообщения, которые не исправит повтор
+func (h *JSONDataHandler) Handle(ctx context.Context, msg kafka.Message) error {
+	const op = "handlers.broker.JSONDataHandler.Handle"
+
+	log := messageLogger(h.log, msg)
+
+	order, err := UnmarshalingOrderDataMessages(msg.Value)
+	if err != nil {
+		return h.deadLetter(ctx, log, msg, StageDecode, err)
+	}
+	log = log.With(slog.String("order_uid", order.OrderUID))
+	// Семантическая проверка до записи в БД, ошибки полей уходят продюсеру в заголовках
+	if err := validation.ValidateOrder(order); err != nil {
+		return h.deadLetter(ctx, log, msg, StageValidate, err)
+	}
+
+	// Запись в бд и кэш
+	if err := h.saver.SaveOrder(ctx, order); err != nil {
+		if !errors.Is(err, orders.ErrOrderRejected) {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		return h.deadLetter(ctx, log, msg, StageStore, err)
+	}
+	log.Info("order stored")
+	return nil
+}
+
+func (h *JSONDataHandler) deadLetter(ctx context.Context, log *slog.Logger, msg kafka.Message, stage string, cause error) error {
+	return writeDeadLetter(ctx, log, h.writer, h.dlqTopic, msg, stage, cause)
+}
@@ -0,0 +1,127 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"test/internal/models"
+	"test/internal/orders"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+const validOrderJSON = `{
+	"order_uid": "b563feb7b2b84b6test",
+	"track_number": "WBILMTESTTRACK",
+	"entry": "WBIL",
+	"delivery": {"name": "Test Testov", "phone": "+9720000000", "email": "test@gmail.com"},
+	"payment": {"transaction": "b563feb7b2b84b6test", "currency": "USD", "provider": "wbpay", "amount": 1817},
+	"items": [{"chrt_id": 9934930, "price": 453, "rid": "ab4219087a764ae0btest", "sale": 30, "total_price": 317}],
+	"date_created": "2021-11-26T06:22:19Z"
+}`
+
+type saverStub struct {
+	saved []string
+	err   error
+}
+
+func (s *saverStub) SaveOrder(_ context.Context, order *models.Order) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.saved = append(s.saved, order.OrderUID)
+	return nil
+}
+
+func TestJSONDataHandler_Handle(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		saveErr   error
+		wantSaved int
+		wantStage string
+		wantErr   bool
+	}{
+		{
+			name:      "valid order is stored",
+			value:     validOrderJSON,
+			wantSaved: 1,
+		},
+		{
+			name:      "broken json goes to dlq",
+			value:     `{"order_uid": `,
+			wantStage: StageDecode,
+		},
+		{
+			name:      "invalid order goes to dlq",
+			value:     `{"order_uid": "b563feb7b2b84b6test", "items": []}`,
+			wantStage: StageValidate,
+		},
+		{
+			name:      "rejected order goes to dlq",
+			value:     validOrderJSON,
+			saveErr:   fmt.Errorf("stub: %w: value too long for type character varying(255)", orders.ErrOrderRejected),
+			wantStage: StageStore,
+		},
+		{
+			// Оффсет не коммитится, консьюмер повторит сообщение
+			name:    "transient store failure is retried",
+			value:   validOrderJSON,
+			saveErr: errors.New("dial tcp 127.0.0.1:5432: connect: connection refused"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saver := &saverStub{err: tt.saveErr}
+			writer := &writerStub{}
+			h := NewJSONDataHandler(slog.New(slog.DiscardHandler), saver, writer, "json_data.dlq")
+
+			msg := kafka.Message{Topic: "json_data", Partition: 2, Offset: 42, Value: []byte(tt.value)}
+			if err := h.Handle(context.Background(), msg); (err != nil) != tt.wantErr {
+				t.Fatalf("Handle() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(saver.saved) != tt.wantSaved {
+				t.Errorf("saved %d orders, want %d", len(saver.saved), tt.wantSaved)
+			}
+			if tt.wantStage == "" {
+				if len(writer.msgs) != 0 {
+					t.Errorf("unexpected dlq messages: %d", len(writer.msgs))
+				}
+				return
+			}
+			if len(writer.msgs) != 1 {
+				t.Fatalf("got %d dlq messages, want 1", len(writer.msgs))
+			}
+			dead := writer.msgs[0]
+			if dead.Topic != "json_data.dlq" || string(dead.Value) != tt.value {
+				t.Errorf("dlq message = %s %q", dead.Topic, dead.Value)
+			}
+			for key, want := range map[string]string{
+				HeaderDLQTopic:     "json_data",
+				HeaderDLQPartition: "2",
+				HeaderDLQOffset:    "42",
+				HeaderDLQStage:     tt.wantStage,
+			} {
+				if got := HeaderValue(dead, key); got != want {
+					t.Errorf("header %s = %q, want %q", key, got, want)
+				}
+			}
+			if HeaderValue(dead, HeaderDLQError) == "" {
+				t.Errorf("header %s is empty", HeaderDLQError)
+			}
+			if hasFieldErrors := HeaderValue(dead, HeaderDLQFieldErrors) != ""; hasFieldErrors != (tt.wantStage == StageValidate) {
+				t.Errorf("header %s present = %v for stage %s", HeaderDLQFieldErrors, hasFieldErrors, tt.wantStage)
+			}
+		})
+	}
+
+	t.Run("dlq write failure", func(t *testing.T) {
+		h := NewJSONDataHandler(slog.New(slog.DiscardHandler), &saverStub{}, &writerStub{err: errors.New("broker down")}, "json_data.dlq")
+		if err := h.Handle(context.Background(), kafka.Message{Value: []byte("not json")}); err == nil {
+			t.Error("Handle() expected error when dlq write fails")
+		}
+	})
+}
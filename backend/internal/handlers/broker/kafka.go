@@ -0,0 +1,77 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"test/internal/config"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Dial подключается к первому доступному брокеру из списка
+func Dial(ctx context.Context, brokers []string) (*kafka.Conn, error) {
+	const op = "handlers.broker.Dial"
+
+	if len(brokers) == 0 {
+		return nil, fmt.Errorf("%s: broker list is empty", op)
+	}
+	var dialer kafka.Dialer
+	var errs []error
+	for _, addr := range brokers {
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err == nil {
+			return conn, nil
+		}
+		errs = append(errs, err)
+	}
+	return nil, fmt.Errorf("%s: %w", op, errors.Join(errs...))
+}
+
+// NewReader создает читателя топика в группе groupID по настройкам из конфига.
+// CommitInterval не задается: CommitMessages синхронный, пачки собирает Consumer
+func NewReader(cfg config.Kafka, topic config.TopicParams, groupID string) *kafka.Reader {
+	return kafka.NewReader(kafka.ReaderConfig{
+		Brokers:  cfg.Brokers,
+		Topic:    topic.Name,
+		GroupID:  groupID,
+		MinBytes: cfg.MinBytes,
+		MaxBytes: cfg.MaxBytes,
+		Dialer: &kafka.Dialer{
+			ClientID:  cfg.ClientID,
+			DualStack: true,
+		},
+	})
+}
+
+// NewWriter создает писателя без фиксированного топика: топик задается в каждом сообщении
+func NewWriter(cfg config.Kafka) *kafka.Writer {
+	return &kafka.Writer{
+		Addr:     kafka.TCP(cfg.Brokers...),
+		Balancer: &kafka.Hash{},
+		Transport: &kafka.Transport{
+			ClientID: cfg.ClientID,
+		},
+	}
+}
+
+// TopicConfigs собирает параметры создания топиков
+func TopicConfigs(topics ...config.TopicParams) []kafka.TopicConfig {
+	configs := make([]kafka.TopicConfig, 0, len(topics))
+	for _, topic := range topics {
+		tc := kafka.TopicConfig{
+			Topic:             topic.Name,
+			NumPartitions:     topic.Partitions,
+			ReplicationFactor: topic.Replication,
+		}
+		if topic.Retention > 0 {
+			tc.ConfigEntries = append(tc.ConfigEntries, kafka.ConfigEntry{
+				ConfigName:  "retention.ms",
+				ConfigValue: strconv.FormatInt(topic.Retention.Milliseconds(), 10),
+			})
+		}
+		configs = append(configs, tc)
+	}
+	return configs
+}
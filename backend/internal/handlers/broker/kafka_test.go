@@ -0,0 +1,28 @@
+package broker
+
+import (
+	"test/internal/config"
+	"testing"
+	"time"
+)
+
+func TestTopicConfigs(t *testing.T) {
+	configs := TopicConfigs(
+		config.TopicParams{Name: "order_id", Partitions: 3, Replication: 1},
+		config.TopicParams{Name: "json_data.dlq", Partitions: 1, Replication: 2, Retention: 7 * 24 * time.Hour},
+	)
+
+	if len(configs) != 2 {
+		t.Fatalf("got %d configs, want 2", len(configs))
+	}
+	if c := configs[0]; c.Topic != "order_id" || c.NumPartitions != 3 || c.ReplicationFactor != 1 || len(c.ConfigEntries) != 0 {
+		t.Errorf("configs[0] = %+v", c)
+	}
+	c := configs[1]
+	if c.Topic != "json_data.dlq" || c.NumPartitions != 1 || c.ReplicationFactor != 2 {
+		t.Errorf("configs[1] = %+v", c)
+	}
+	if len(c.ConfigEntries) != 1 || c.ConfigEntries[0].ConfigName != "retention.ms" || c.ConfigEntries[0].ConfigValue != "604800000" {
+		t.Errorf("retention entry = %+v", c.ConfigEntries)
+	}
+}
@@ -0,0 +1,106 @@
+package broker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// offsetTracker следит, до какого оффсета каждой партиции все сообщения обработаны.
+// Обработчики завершают сообщения не по порядку, а коммит оффсета подтверждает
+// и все предыдущие, поэтому коммитится только непрерывный обработанный префикс
+type offsetTracker struct {
+	mu         sync.Mutex
+	partitions map[int]*partitionOffsets
+	// Сколько сообщений вошло в префиксы, но еще не закоммичено
+	ready      int
+	firstReady time.Time
+}
+
+type partitionOffsets struct {
+	// Прочитанные и еще не подтвержденные сообщения в порядке чтения
+	inflight []kafka.Message
+	done     map[int64]bool
+	// Последнее сообщение обработанного префикса, nil - коммитить нечего
+	commit *kafka.Message
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{partitions: make(map[int]*partitionOffsets)}
+}
+
+// start вызывается в порядке чтения, до передачи сообщения обработчику
+func (t *offsetTracker) start(msg kafka.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p, ok := t.partitions[msg.Partition]
+	if !ok {
+		p = &partitionOffsets{done: make(map[int64]bool)}
+		t.partitions[msg.Partition] = p
+	}
+	p.inflight = append(p.inflight, msg)
+}
+
+// done отмечает сообщение обработанным и сдвигает префикс партиции
+func (t *offsetTracker) done(msg kafka.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p, ok := t.partitions[msg.Partition]
+	if !ok {
+		return
+	}
+	p.done[msg.Offset] = true
+	for len(p.inflight) > 0 && p.done[p.inflight[0].Offset] {
+		head := p.inflight[0]
+		delete(p.done, head.Offset)
+		p.inflight = p.inflight[1:]
+		p.commit = &head
+		if t.ready == 0 {
+			t.firstReady = time.Now()
+		}
+		t.ready++
+	}
+}
+
+// pending возвращает сообщения для коммита (по одному на партицию), их общее
+// число и время, когда первое из них стало готово
+func (t *offsetTracker) pending() ([]kafka.Message, int, time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	msgs := make([]kafka.Message, 0, len(t.partitions))
+	for _, p := range t.partitions {
+		if p.commit != nil {
+			msgs = append(msgs, *p.commit)
+		}
+	}
+	return msgs, t.ready, t.firstReady
+}
+
+// committed снимает закоммиченные сообщения. Префиксы, сдвинувшиеся после
+// pending, остаются для следующего коммита
+func (t *offsetTracker) committed(msgs []kafka.Message, count int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, msg := range msgs {
+		if p, ok := t.partitions[msg.Partition]; ok && p.commit != nil && p.commit.Offset == msg.Offset {
+			p.commit = nil
+		}
+	}
+	t.ready -= count
+	if t.ready > 0 {
+		t.firstReady = time.Now()
+	}
+}
+
+// retryLater откладывает следующую попытку коммита на интервал
+func (t *offsetTracker) retryLater() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.firstReady = time.Now()
+}
@@ -0,0 +1,41 @@
+package broker
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestOffsetTracker_CommitsContiguousPrefix(t *testing.T) {
+	tracker := newOffsetTracker()
+	msgs := []kafka.Message{
+		{Partition: 0, Offset: 10},
+		{Partition: 0, Offset: 11},
+		{Partition: 0, Offset: 12},
+		{Partition: 1, Offset: 5},
+	}
+	for _, msg := range msgs {
+		tracker.start(msg)
+	}
+
+	// 11 и 12 обработаны раньше 10: коммитить в партиции 0 нечего
+	tracker.done(msgs[2])
+	tracker.done(msgs[1])
+	tracker.done(msgs[3])
+	pending, ready, _ := tracker.pending()
+	if ready != 1 || !reflect.DeepEqual(pending, []kafka.Message{msgs[3]}) {
+		t.Fatalf("pending = %v (%d), want only partition 1", pending, ready)
+	}
+	tracker.committed(pending, ready)
+
+	tracker.done(msgs[0])
+	pending, ready, _ = tracker.pending()
+	if ready != 3 || len(pending) != 1 || pending[0].Offset != 12 {
+		t.Fatalf("pending = %v (%d), want offset 12 of partition 0", pending, ready)
+	}
+	tracker.committed(pending, ready)
+	if _, ready, _ := tracker.pending(); ready != 0 {
+		t.Errorf("ready after commit = %d", ready)
+	}
+}
@@ -0,0 +1,65 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"test/internal/models"
+	"test/internal/orders"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// OrderEraser - исполнитель запросов на удаление заказов
+type OrderEraser interface {
+	EraseOrder(ctx context.Context, req models.ErasureRequest, source string) (*models.ErasureAudit, error)
+}
+
+// OrderErasureHandler удаляет заказы по запросам из order_erasure. Сообщения, которые
+// не удалось разобрать, уходят в dead-letter топик
+type OrderErasureHandler struct {
+	log      *slog.Logger
+	eraser   OrderEraser
+	writer   MessageWriter
+	dlqTopic string
+}
+
+func NewOrderErasureHandler(log *slog.Logger, eraser OrderEraser, writer MessageWriter, dlqTopic string) *OrderErasureHandler {
+	return &OrderErasureHandler{
+		log:      log,
+		eraser:   eraser,
+		writer:   writer,
+		dlqTopic: dlqTopic,
+	}
+}
+
+// Handle возвращает ошибку, если заказ не удалось удалить (запрос на удаление нельзя
+// потерять, консьюмер повторит его) или сообщение не удалось отправить в dead-letter топик.
+// Неизвестный заказ не ошибка: сообщение могло быть прочитано повторно после успешного удаления
+func (h *OrderErasureHandler) Handle(ctx context.Context, msg kafka.Message) error {
+	const op = "handlers.broker.OrderErasureHandler.Handle"
+
+	log := messageLogger(h.log, msg)
+
+	var req models.ErasureRequest
+	if err := json.Unmarshal(msg.Value, &req); err != nil {
+		return writeDeadLetter(ctx, log, h.writer, h.dlqTopic, msg, StageDecode, err)
+	}
+	log = log.With(slog.String("order_uid", req.OrderUID), slog.String("requested_by", req.RequestedBy))
+	if req.OrderUID == "" {
+		return writeDeadLetter(ctx, log, h.writer, h.dlqTopic, msg, StageValidate, errors.New("order_uid is required"))
+	}
+
+	audit, err := h.eraser.EraseOrder(ctx, req, models.ErasureSourceKafka)
+	if errors.Is(err, orders.ErrOrderNotFound) {
+		log.Info("order to erase not found, nothing to do")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("order erased", slog.String("mode", audit.Mode))
+	return nil
+}
@@ -0,0 +1,91 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"test/internal/models"
+	"test/internal/orders"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type eraserStub struct {
+	erased []models.ErasureRequest
+	err    error
+}
+
+func (e *eraserStub) EraseOrder(_ context.Context, req models.ErasureRequest, source string) (*models.ErasureAudit, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	e.erased = append(e.erased, req)
+	return &models.ErasureAudit{OrderUID: req.OrderUID, Mode: models.ErasureDelete, Source: source}, nil
+}
+
+func TestOrderErasureHandler_Handle(t *testing.T) {
+	tests := []struct {
+		name       string
+		value      string
+		eraseErr   error
+		wantErased int
+		wantStage  string
+		wantErr    bool
+	}{
+		{
+			name:       "order is erased",
+			value:      `{"order_uid": "b563feb7b2b84b6test", "requested_by": "ticket-1"}`,
+			wantErased: 1,
+		},
+		{
+			name:     "unknown order is skipped",
+			value:    `{"order_uid": "b563feb7b2b84b6test"}`,
+			eraseErr: fmt.Errorf("stub: %w", orders.ErrOrderNotFound),
+		},
+		{
+			name:      "broken json goes to dlq",
+			value:     `{"order_uid": `,
+			wantStage: StageDecode,
+		},
+		{
+			name:      "missing order_uid goes to dlq",
+			value:     `{"requested_by": "ticket-1"}`,
+			wantStage: StageValidate,
+		},
+		{
+			name:     "store failure is retried",
+			value:    `{"order_uid": "b563feb7b2b84b6test"}`,
+			eraseErr: errors.New("connection refused"),
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eraser := &eraserStub{err: tt.eraseErr}
+			writer := &writerStub{}
+			h := NewOrderErasureHandler(slog.New(slog.DiscardHandler), eraser, writer, "json_data.dlq")
+
+			msg := kafka.Message{Topic: "order_erasure", Value: []byte(tt.value)}
+			if err := h.Handle(context.Background(), msg); (err != nil) != tt.wantErr {
+				t.Fatalf("Handle() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(eraser.erased) != tt.wantErased {
+				t.Errorf("erased %d orders, want %d", len(eraser.erased), tt.wantErased)
+			}
+			if tt.wantStage == "" {
+				if len(writer.msgs) != 0 {
+					t.Errorf("unexpected dlq messages: %d", len(writer.msgs))
+				}
+				return
+			}
+			if len(writer.msgs) != 1 {
+				t.Fatalf("got %d dlq messages, want 1", len(writer.msgs))
+			}
+			if got := HeaderValue(writer.msgs[0], HeaderDLQStage); got != tt.wantStage {
+				t.Errorf("stage = %q, want %q", got, tt.wantStage)
+			}
+		})
+	}
+}
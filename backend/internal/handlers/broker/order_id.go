@@ -0,0 +1,101 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"test/internal/logger"
+	"test/internal/metrics"
+	"test/internal/models"
+	"test/internal/orders"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// OrderProvider - источник заказов для обработчика order_id
+type OrderProvider interface {
+	GetOrder(ctx context.Context, orderUID string) (*models.Order, error)
+}
+
+// MessageWriter - часть kafka.Writer, необходимая обработчикам
+type MessageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// OrderIDHandler отвечает на запросы order_id конвертом models.OrderResponse
+type OrderIDHandler struct {
+	log           *slog.Logger
+	provider      OrderProvider
+	writer        MessageWriter
+	responseTopic string
+	replyTopics   map[string]bool
+}
+
+// replyTopics - топики, которые клиент может указать в reply_to, кроме responseTopic.
+// Запрос с другим reply_to получает ответ в responseTopic
+func NewOrderIDHandler(log *slog.Logger, provider OrderProvider, writer MessageWriter, responseTopic string, replyTopics ...string) *OrderIDHandler {
+	allowed := map[string]bool{responseTopic: true}
+	for _, topic := range replyTopics {
+		allowed[topic] = true
+	}
+	return &OrderIDHandler{
+		log:           log,
+		provider:      provider,
+		writer:        writer,
+		responseTopic: responseTopic,
+		replyTopics:   allowed,
+	}
+}
+
+// Handle ищет заказ и отправляет ответ. Ненайденный заказ и ошибки хранилища
+// отдаются клиенту в поле status, ошибка возвращается только если ответ не удалось отправить
+func (h *OrderIDHandler) Handle(ctx context.Context, msg kafka.Message) error {
+	const op = "handlers.broker.OrderIDHandler.Handle"
+
+	// Получим интересуемый ID для поиска данных по заказу
+	orderUID := string(msg.Value)
+	log := messageLogger(h.log, msg).With(slog.String("order_uid", orderUID))
+	response := h.lookup(ctx, log, orderUID)
+
+	value, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("%s: marshal response: %w", op, err)
+	}
+
+	// Ответ наследует ключ и заголовки запроса (correlation_id, reply_to)
+	reply, allowed := NewReply(msg, value, h.responseTopic, h.replyTopics)
+	if !allowed {
+		log.Warn("reply_to topic is not allowed, replying to default topic",
+			slog.String("reply_to", HeaderValue(msg, HeaderReplyTo)), slog.String("topic", reply.Topic))
+	}
+	start := time.Now()
+	err = h.writer.WriteMessages(ctx, reply)
+	metrics.ObserveWrite(reply.Topic, start, err)
+	if err != nil {
+		return fmt.Errorf("%s: write response: %w", op, err)
+	}
+	log.Debug("order response sent", slog.String("status", string(response.Status)))
+	return nil
+}
+
+func (h *OrderIDHandler) lookup(ctx context.Context, log *slog.Logger, orderUID string) models.OrderResponse {
+	const op = "handlers.broker.OrderIDHandler.lookup"
+
+	if orderUID == "" {
+		return models.OrderResponse{Status: models.StatusError, Error: "order_uid is empty"}
+	}
+
+	order, err := h.provider.GetOrder(ctx, orderUID)
+	switch {
+	case errors.Is(err, orders.ErrOrderNotFound):
+		return models.OrderResponse{Status: models.StatusNotFound, Error: fmt.Sprintf("order %s not found", orderUID)}
+	case err != nil:
+		log.Error("failed to get order", slog.String("op", op), logger.Err(err))
+		return models.OrderResponse{Status: models.StatusError, Error: "failed to get order"}
+	}
+
+	return models.OrderResponse{Status: models.StatusOK, Order: order}
+}
@@ -0,0 +1,110 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"test/internal/models"
+	"test/internal/orders"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type providerStub map[string]*models.Order
+
+func (p providerStub) GetOrder(_ context.Context, orderUID string) (*models.Order, error) {
+	if orderUID == "broken" {
+		return nil, errors.New("connection refused")
+	}
+	order, ok := p[orderUID]
+	if !ok {
+		return nil, fmt.Errorf("stub: %w", orders.ErrOrderNotFound)
+	}
+	return order, nil
+}
+
+type writerStub struct {
+	msgs []kafka.Message
+	err  error
+}
+
+func (w *writerStub) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.msgs = append(w.msgs, msgs...)
+	return nil
+}
+
+func TestOrderIDHandler_Handle(t *testing.T) {
+	provider := providerStub{"b563feb7b2b84b6test": {OrderUID: "b563feb7b2b84b6test"}}
+
+	tests := []struct {
+		name       string
+		value      string
+		wantStatus models.ResponseStatus
+	}{
+		{name: "found", value: "b563feb7b2b84b6test", wantStatus: models.StatusOK},
+		{name: "not found", value: "unknown", wantStatus: models.StatusNotFound},
+		{name: "storage failure", value: "broken", wantStatus: models.StatusError},
+		{name: "empty uid", value: "", wantStatus: models.StatusError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer := &writerStub{}
+			h := NewOrderIDHandler(slog.New(slog.DiscardHandler), provider, writer, "order_response")
+
+			if err := h.Handle(context.Background(), kafka.Message{Value: []byte(tt.value)}); err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+			if len(writer.msgs) != 1 {
+				t.Fatalf("got %d replies, want 1", len(writer.msgs))
+			}
+
+			var resp models.OrderResponse
+			if err := json.Unmarshal(writer.msgs[0].Value, &resp); err != nil {
+				t.Fatalf("unmarshal reply: %v", err)
+			}
+			if resp.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", resp.Status, tt.wantStatus)
+			}
+			if (resp.Order != nil) != (tt.wantStatus == models.StatusOK) {
+				t.Errorf("order = %v for status %q", resp.Order, resp.Status)
+			}
+		})
+	}
+
+	// Запрос с reply_to=order_id не должен вернуться в order_id и зациклиться
+	t.Run("reply_to is restricted", func(t *testing.T) {
+		h := NewOrderIDHandler(slog.New(slog.DiscardHandler), provider, &writerStub{}, "order_response", "order_response.client1")
+		for replyTo, want := range map[string]string{
+			"order_id":               "order_response",
+			"no-such-topic":          "order_response",
+			"order_response.client1": "order_response.client1",
+		} {
+			writer := &writerStub{}
+			h.writer = writer
+			msg := kafka.Message{
+				Topic:   "order_id",
+				Value:   []byte("b563feb7b2b84b6test"),
+				Headers: []kafka.Header{{Key: HeaderReplyTo, Value: []byte(replyTo)}},
+			}
+			if err := h.Handle(context.Background(), msg); err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+			if len(writer.msgs) != 1 || writer.msgs[0].Topic != want {
+				t.Errorf("reply_to %s: replies = %v, want one to %s", replyTo, writer.msgs, want)
+			}
+		}
+	})
+
+	t.Run("write failure", func(t *testing.T) {
+		h := NewOrderIDHandler(slog.New(slog.DiscardHandler), provider, &writerStub{err: errors.New("broker down")}, "order_response")
+		if err := h.Handle(context.Background(), kafka.Message{Value: []byte("unknown")}); err == nil {
+			t.Error("Handle() expected error when reply cannot be written")
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"test/internal/models"
+	"test/internal/orders"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// StatusUpdater - получатель обновлений статуса из order_status
+type StatusUpdater interface {
+	UpdateStatus(ctx context.Context, update models.StatusUpdate) error
+}
+
+// OrderStatusHandler применяет обновления статусов товаров. Сообщения, которые не удалось
+// разобрать, с неизвестным заказом или товаром и с запрещенным переходом статуса уходят
+// в dead-letter топик
+type OrderStatusHandler struct {
+	log      *slog.Logger
+	updater  StatusUpdater
+	writer   MessageWriter
+	dlqTopic string
+}
+
+func NewOrderStatusHandler(log *slog.Logger, updater StatusUpdater, writer MessageWriter, dlqTopic string) *OrderStatusHandler {
+	return &OrderStatusHandler{
+		log:      log,
+		updater:  updater,
+		writer:   writer,
+		dlqTopic: dlqTopic,
+	}
+}
+
+// Handle возвращает ошибку, если статус не удалось обновить из-за временной ошибки хранилища
+// или сообщение не удалось отправить в dead-letter топик: консьюмер повторит обработку
+func (h *OrderStatusHandler) Handle(ctx context.Context, msg kafka.Message) error {
+	const op = "handlers.broker.OrderStatusHandler.Handle"
+
+	log := messageLogger(h.log, msg)
+
+	var update models.StatusUpdate
+	if err := json.Unmarshal(msg.Value, &update); err != nil {
+		return writeDeadLetter(ctx, log, h.writer, h.dlqTopic, msg, StageDecode, err)
+	}
+	log = log.With(slog.String("order_uid", update.OrderUID), slog.String("rid", update.RID), slog.Int("status", update.Status))
+	if update.OrderUID == "" || update.Status <= 0 {
+		err := errors.New("order_uid and positive status are required")
+		return writeDeadLetter(ctx, log, h.writer, h.dlqTopic, msg, StageValidate, err)
+	}
+
+	if err := h.updater.UpdateStatus(ctx, update); err != nil {
+		if errors.Is(err, orders.ErrOrderNotFound) || errors.Is(err, orders.ErrItemNotFound) ||
+			errors.Is(err, orders.ErrInvalidTransition) {
+			return writeDeadLetter(ctx, log, h.writer, h.dlqTopic, msg, StageValidate, err)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("order status updated")
+	return nil
+}
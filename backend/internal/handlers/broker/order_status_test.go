@@ -0,0 +1,95 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"test/internal/models"
+	"test/internal/orders"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type updaterStub struct {
+	updates []models.StatusUpdate
+	err     error
+}
+
+func (u *updaterStub) UpdateStatus(_ context.Context, update models.StatusUpdate) error {
+	if u.err != nil {
+		return u.err
+	}
+	u.updates = append(u.updates, update)
+	return nil
+}
+
+func TestOrderStatusHandler_Handle(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		updateErr   error
+		wantUpdated int
+		wantStage   string
+		wantErr     bool
+	}{
+		{
+			name:        "item status is updated",
+			value:       `{"order_uid": "b563feb7b2b84b6test", "rid": "ab4219087a764ae0btest", "status": 203}`,
+			wantUpdated: 1,
+		},
+		{
+			name:      "broken json goes to dlq",
+			value:     `{"order_uid": `,
+			wantStage: StageDecode,
+		},
+		{
+			name:      "missing status goes to dlq",
+			value:     `{"order_uid": "b563feb7b2b84b6test"}`,
+			wantStage: StageValidate,
+		},
+		{
+			name:      "invalid transition goes to dlq",
+			value:     `{"order_uid": "b563feb7b2b84b6test", "status": 202}`,
+			updateErr: fmt.Errorf("stub: %w", orders.ErrInvalidTransition),
+			wantStage: StageValidate,
+		},
+		{
+			name:      "store failure is retried",
+			value:     `{"order_uid": "b563feb7b2b84b6test", "status": 204}`,
+			updateErr: errors.New("connection refused"),
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updater := &updaterStub{err: tt.updateErr}
+			writer := &writerStub{}
+			h := NewOrderStatusHandler(slog.New(slog.DiscardHandler), updater, writer, "json_data.dlq")
+
+			msg := kafka.Message{Topic: "order_status", Value: []byte(tt.value)}
+			if err := h.Handle(context.Background(), msg); (err != nil) != tt.wantErr {
+				t.Fatalf("Handle() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(updater.updates) != tt.wantUpdated {
+				t.Errorf("applied %d updates, want %d", len(updater.updates), tt.wantUpdated)
+			}
+			if tt.wantStage == "" {
+				if len(writer.msgs) != 0 {
+					t.Errorf("unexpected dlq messages: %d", len(writer.msgs))
+				}
+				return
+			}
+			if len(writer.msgs) != 1 {
+				t.Fatalf("got %d dlq messages, want 1", len(writer.msgs))
+			}
+			if got := HeaderValue(writer.msgs[0], HeaderDLQStage); got != tt.wantStage {
+				t.Errorf("stage = %q, want %q", got, tt.wantStage)
+			}
+			if got := HeaderValue(writer.msgs[0], HeaderDLQTopic); got != "order_status" {
+				t.Errorf("original topic = %q, want order_status", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package rest
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"test/internal/logger"
+	"test/internal/models"
+	"test/internal/orders"
+)
+
+// OrderEraser - исполнитель запросов на удаление заказов
+type OrderEraser interface {
+	EraseOrder(ctx context.Context, req models.ErasureRequest, source string) (*models.ErasureAudit, error)
+}
+
+// EraseOrder удаляет или обезличивает заказ по order_uid и отдает запись журнала удалений.
+// Запрос принимается только с токеном token в заголовке Authorization: Bearer. Параметр
+// requested_by (номер обращения или оператор) обязателен и попадает в журнал; он не
+// проверяется, поэтому токен выдается только операторам, которым доверяют этот журнал
+func EraseOrder(log *slog.Logger, eraser OrderEraser, token string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.rest.EraseOrder"
+
+		req := models.ErasureRequest{
+			OrderUID:    r.PathValue("order_uid"),
+			RequestedBy: r.URL.Query().Get("requested_by"),
+		}
+		log := log.With(slog.String("op", op), slog.String("order_uid", req.OrderUID))
+		if !authorized(r, token) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeJSON(log, w, http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+			return
+		}
+		if req.OrderUID == "" {
+			writeJSON(log, w, http.StatusBadRequest, errorResponse{Error: "order_uid is required"})
+			return
+		}
+		if req.RequestedBy == "" {
+			writeJSON(log, w, http.StatusBadRequest, errorResponse{Error: "requested_by is required"})
+			return
+		}
+
+		audit, err := eraser.EraseOrder(r.Context(), req, models.ErasureSourceHTTP)
+		if err != nil {
+			if errors.Is(err, orders.ErrOrderNotFound) {
+				writeJSON(log, w, http.StatusNotFound, errorResponse{Error: "order not found"})
+				return
+			}
+			log.Error("failed to erase order", logger.Err(err))
+			writeJSON(log, w, http.StatusInternalServerError, errorResponse{Error: "internal error"})
+			return
+		}
+
+		log.Info("order erased", slog.String("mode", audit.Mode), slog.String("requested_by", audit.RequestedBy))
+		writeJSON(log, w, http.StatusOK, audit)
+	}
+}
+
+// authorized сравнивает токен за постоянное время, чтобы его нельзя было подобрать по задержке ответа
+func authorized(r *http.Request, token string) bool {
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
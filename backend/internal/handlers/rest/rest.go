@@ -0,0 +1,73 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"test/internal/health"
+	"test/internal/logger"
+	"test/internal/metrics"
+	"test/internal/models"
+	"test/internal/orders"
+)
+
+// OrderProvider - источник заказов для HTTP обработчиков
+type OrderProvider interface {
+	GetOrder(ctx context.Context, orderUID string) (*models.Order, error)
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// NewRouter собирает маршруты HTTP сервера. live и ready - проверки для /livez и /readyz.
+// Удаление заказов доступно только с eraseToken, без него маршрут не регистрируется
+func NewRouter(log *slog.Logger, provider OrderProvider, searcher OrderSearcher, eraser OrderEraser, eraseToken string, live, ready *health.Checker) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /orders", SearchOrders(log, searcher))
+	mux.HandleFunc("GET /orders/{order_uid}", GetOrder(log, provider))
+	if eraser != nil && eraseToken != "" {
+		mux.HandleFunc("DELETE /orders/{order_uid}", EraseOrder(log, eraser, eraseToken))
+	}
+	mux.Handle("GET /metrics", metrics.Handler())
+	mux.Handle("GET /livez", live.Handler())
+	mux.Handle("GET /readyz", ready.Handler())
+	return mux
+}
+
+// GetOrder отдает заказ по order_uid
+func GetOrder(log *slog.Logger, provider OrderProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.rest.GetOrder"
+
+		orderUID := r.PathValue("order_uid")
+		log := log.With(slog.String("op", op), slog.String("order_uid", orderUID))
+		if orderUID == "" {
+			writeJSON(log, w, http.StatusBadRequest, errorResponse{Error: "order_uid is required"})
+			return
+		}
+
+		order, err := provider.GetOrder(r.Context(), orderUID)
+		if err != nil {
+			if errors.Is(err, orders.ErrOrderNotFound) {
+				writeJSON(log, w, http.StatusNotFound, errorResponse{Error: "order not found"})
+				return
+			}
+			log.Error("failed to get order", logger.Err(err))
+			writeJSON(log, w, http.StatusInternalServerError, errorResponse{Error: "internal error"})
+			return
+		}
+
+		writeJSON(log, w, http.StatusOK, order)
+	}
+}
+
+func writeJSON(log *slog.Logger, w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Warn("failed to write response", logger.Err(err))
+	}
+}
@@ -0,0 +1,225 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"test/internal/health"
+	"test/internal/models"
+	"test/internal/orders"
+	"test/internal/storage"
+	"testing"
+	"time"
+)
+
+type providerStub map[string]*models.Order
+
+func (p providerStub) GetOrder(_ context.Context, orderUID string) (*models.Order, error) {
+	if orderUID == "broken" {
+		return nil, errors.New("connection refused")
+	}
+	order, ok := p[orderUID]
+	if !ok {
+		return nil, fmt.Errorf("stub: %w", orders.ErrOrderNotFound)
+	}
+	return order, nil
+}
+
+func TestGetOrder(t *testing.T) {
+	provider := providerStub{
+		"b563feb7b2b84b6test": {OrderUID: "b563feb7b2b84b6test", TrackNumber: "WBILMTESTTRACK"},
+	}
+	router := NewRouter(slog.New(slog.DiscardHandler), provider, nil, nil, "", health.NewChecker(time.Second), health.NewChecker(time.Second))
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantUID    string
+	}{
+		{
+			name:       "order found",
+			path:       "/orders/b563feb7b2b84b6test",
+			wantStatus: http.StatusOK,
+			wantUID:    "b563feb7b2b84b6test",
+		},
+		{
+			name:       "order not found",
+			path:       "/orders/unknown",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "storage failure",
+			path:       "/orders/broken",
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", ct)
+			}
+			if tt.wantUID == "" {
+				var body errorResponse
+				if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || body.Error == "" {
+					t.Errorf("expected JSON error body, got %q (err %v)", rec.Body.String(), err)
+				}
+				return
+			}
+			var order models.Order
+			if err := json.NewDecoder(rec.Body).Decode(&order); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if order.OrderUID != tt.wantUID {
+				t.Errorf("order_uid = %q, want %q", order.OrderUID, tt.wantUID)
+			}
+		})
+	}
+}
+
+type searcherStub struct {
+	query storage.SearchQuery
+}
+
+func (s *searcherStub) SearchOrders(_ context.Context, query storage.SearchQuery) (*storage.SearchResult, error) {
+	s.query = query
+	switch query.Cursor {
+	case "bad":
+		return nil, fmt.Errorf("stub: %w", orders.ErrInvalidSearch)
+	case "broken":
+		return nil, errors.New("connection refused")
+	}
+	return &storage.SearchResult{
+		Orders:     []models.Order{{OrderUID: "b563feb7b2b84b6test"}},
+		NextCursor: "next",
+	}, nil
+}
+
+func TestSearchOrders(t *testing.T) {
+	searcher := &searcherStub{}
+	router := NewRouter(slog.New(slog.DiscardHandler), providerStub{}, searcher, nil, "", health.NewChecker(time.Second), health.NewChecker(time.Second))
+
+	rec := httptest.NewRecorder()
+	path := "/orders?customer_id=test&brand=Vivienne+Sabo&date_from=2021-11-26T00:00:00Z&sort=created_at&order=asc&limit=5&details=true"
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var body searchResponse
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if len(body.Orders) != 1 || body.NextCursor != "next" {
+		t.Errorf("body = %+v", body)
+	}
+	q := searcher.query
+	if q.Filter.CustomerID != "test" || q.Filter.Brand != "Vivienne Sabo" || q.Filter.DateFrom.IsZero() ||
+		q.SortBy != storage.SortByCreatedAt || !q.Ascending || q.Limit != 5 || !q.WithDetails {
+		t.Errorf("parsed query = %+v", q)
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+	}{
+		{name: "bad date", path: "/orders?date_to=yesterday", wantStatus: http.StatusBadRequest},
+		{name: "bad order", path: "/orders?order=up", wantStatus: http.StatusBadRequest},
+		{name: "bad limit", path: "/orders?limit=-1", wantStatus: http.StatusBadRequest},
+		{name: "invalid cursor", path: "/orders?cursor=bad", wantStatus: http.StatusBadRequest},
+		{name: "storage failure", path: "/orders?cursor=broken", wantStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+type eraserStub struct{}
+
+func (eraserStub) EraseOrder(_ context.Context, req models.ErasureRequest, source string) (*models.ErasureAudit, error) {
+	switch req.OrderUID {
+	case "unknown":
+		return nil, fmt.Errorf("stub: %w", orders.ErrOrderNotFound)
+	case "broken":
+		return nil, errors.New("connection refused")
+	}
+	return &models.ErasureAudit{OrderUID: req.OrderUID, Mode: models.ErasureDelete, Source: source, RequestedBy: req.RequestedBy}, nil
+}
+
+func TestEraseOrder(t *testing.T) {
+	router := NewRouter(slog.New(slog.DiscardHandler), providerStub{}, nil, eraserStub{}, "secret", health.NewChecker(time.Second), health.NewChecker(time.Second))
+
+	tests := []struct {
+		name       string
+		path       string
+		token      string
+		wantStatus int
+	}{
+		{name: "order erased", path: "/orders/b563feb7b2b84b6test?requested_by=ticket-1", token: "secret", wantStatus: http.StatusOK},
+		{name: "order not found", path: "/orders/unknown?requested_by=ticket-1", token: "secret", wantStatus: http.StatusNotFound},
+		{name: "storage failure", path: "/orders/broken?requested_by=ticket-1", token: "secret", wantStatus: http.StatusInternalServerError},
+		{name: "requested_by missing", path: "/orders/b563feb7b2b84b6test", token: "secret", wantStatus: http.StatusBadRequest},
+		{name: "token missing", path: "/orders/b563feb7b2b84b6test?requested_by=ticket-1", wantStatus: http.StatusUnauthorized},
+		{name: "wrong token", path: "/orders/b563feb7b2b84b6test?requested_by=ticket-1", token: "guess", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodDelete, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var audit models.ErasureAudit
+			if err := json.NewDecoder(rec.Body).Decode(&audit); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if audit.Source != models.ErasureSourceHTTP || audit.RequestedBy != "ticket-1" {
+				t.Errorf("audit = %+v", audit)
+			}
+		})
+	}
+
+	// Без токена в конфиге удаление по HTTP выключено
+	router = NewRouter(slog.New(slog.DiscardHandler), providerStub{}, nil, eraserStub{}, "", health.NewChecker(time.Second), health.NewChecker(time.Second))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/orders/b563feb7b2b84b6test?requested_by=ticket-1", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status without erasure token = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewRouter(slog.New(slog.DiscardHandler), providerStub{}, nil, nil, "", health.NewChecker(time.Second), health.NewChecker(time.Second)).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if !strings.Contains(rec.Body.String(), "go_goroutines") {
+		t.Error("metrics output does not contain default collectors")
+	}
+}
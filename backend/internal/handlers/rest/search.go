@@ -0,0 +1,115 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"test/internal/logger"
+	"test/internal/models"
+	"test/internal/orders"
+	"test/internal/storage"
+	"time"
+)
+
+// OrderSearcher - поиск заказов для HTTP обработчиков
+type OrderSearcher interface {
+	SearchOrders(ctx context.Context, query storage.SearchQuery) (*storage.SearchResult, error)
+}
+
+type searchResponse struct {
+	Orders     []models.Order `json:"orders"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// SearchOrders ищет заказы по параметрам запроса:
+//
+//	customer_id, track_number, delivery_service, locale, provider, currency, brand - точное совпадение;
+//	date_from, date_to - диапазон date_created в RFC 3339, date_to не включается;
+//	sort - date_created (по умолчанию) или created_at, order - desc (по умолчанию) или asc;
+//	limit - размер страницы, cursor - next_cursor предыдущей страницы;
+//	details=true - вместе с delivery, payment и items
+func SearchOrders(log *slog.Logger, searcher OrderSearcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.rest.SearchOrders"
+
+		log := log.With(slog.String("op", op))
+		query, err := parseSearchQuery(r.URL.Query())
+		if err != nil {
+			writeJSON(log, w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+			return
+		}
+
+		result, err := searcher.SearchOrders(r.Context(), query)
+		if err != nil {
+			if errors.Is(err, orders.ErrInvalidSearch) {
+				writeJSON(log, w, http.StatusBadRequest, errorResponse{Error: "invalid sort or cursor"})
+				return
+			}
+			log.Error("failed to search orders", logger.Err(err))
+			writeJSON(log, w, http.StatusInternalServerError, errorResponse{Error: "internal error"})
+			return
+		}
+
+		writeJSON(log, w, http.StatusOK, searchResponse{Orders: result.Orders, NextCursor: result.NextCursor})
+	}
+}
+
+func parseSearchQuery(values url.Values) (storage.SearchQuery, error) {
+	query := storage.SearchQuery{
+		Filter: storage.OrderFilter{
+			CustomerID:      values.Get("customer_id"),
+			TrackNumber:     values.Get("track_number"),
+			DeliveryService: values.Get("delivery_service"),
+			Locale:          values.Get("locale"),
+			Provider:        values.Get("provider"),
+			Currency:        values.Get("currency"),
+			Brand:           values.Get("brand"),
+		},
+		SortBy: values.Get("sort"),
+		Cursor: values.Get("cursor"),
+	}
+
+	var err error
+	if query.Filter.DateFrom, err = parseTime(values, "date_from"); err != nil {
+		return query, err
+	}
+	if query.Filter.DateTo, err = parseTime(values, "date_to"); err != nil {
+		return query, err
+	}
+	switch values.Get("order") {
+	case "", "desc":
+	case "asc":
+		query.Ascending = true
+	default:
+		return query, fmt.Errorf("order must be asc or desc")
+	}
+	if v := values.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return query, fmt.Errorf("limit must be a positive number")
+		}
+		query.Limit = limit
+	}
+	if v := values.Get("details"); v != "" {
+		if query.WithDetails, err = strconv.ParseBool(v); err != nil {
+			return query, fmt.Errorf("details must be true or false")
+		}
+	}
+	return query, nil
+}
+
+func parseTime(values url.Values, name string) (time.Time, error) {
+	v := values.Get(name)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be an RFC 3339 time", name)
+	}
+	return t, nil
+}
@@ -0,0 +1,159 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// CheckFunc возвращает nil, если проверяемая зависимость в порядке
+type CheckFunc func(ctx context.Context) error
+
+type CheckResult struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Checker хранит именованные проверки и выполняет их параллельно
+type Checker struct {
+	mu      sync.RWMutex
+	checks  map[string]CheckFunc
+	timeout time.Duration
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{
+		checks:  make(map[string]CheckFunc),
+		timeout: timeout,
+	}
+}
+
+// Add регистрирует проверку; проверка с тем же именем заменяется
+func (c *Checker) Add(name string, check CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checks[name] = check
+}
+
+// Run выполняет все проверки; общий статус up, только если все проверки прошли
+func (c *Checker) Run(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := make(map[string]CheckFunc, len(c.checks))
+	for name, check := range c.checks {
+		checks[name] = check
+	}
+	c.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]CheckResult, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			start := time.Now()
+			err := check(ctx)
+			result := CheckResult{Status: StatusUp, Duration: time.Since(start).String()}
+			if err != nil {
+				result.Status = StatusDown
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if err != nil {
+				report.Status = StatusDown
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+// Handler отдает отчет проверок: 200, если все в порядке, иначе 503
+func (c *Checker) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := c.Run(r.Context())
+
+		status := http.StatusOK
+		if report.Status != StatusUp {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(report)
+	}
+}
+
+// Heartbeat отмечает, что цикл (например, консьюмер) жив. Цикл вызывает Beat
+// на каждой итерации, а Check сообщает об ошибке, если отметок давно не было
+type Heartbeat struct {
+	last atomic.Int64
+}
+
+// Beat и Stop допускают nil, чтобы heartbeat был необязательным
+func (h *Heartbeat) Beat() {
+	if h != nil {
+		h.last.Store(time.Now().UnixNano())
+	}
+}
+
+// Stop снимает отметку, после этого проверка не проходит
+func (h *Heartbeat) Stop() {
+	if h != nil {
+		h.last.Store(0)
+	}
+}
+
+func (h *Heartbeat) Check(maxAge time.Duration) CheckFunc {
+	return func(context.Context) error {
+		last := h.last.Load()
+		if last == 0 {
+			return errors.New("not running")
+		}
+		if age := time.Since(time.Unix(0, last)); age > maxAge {
+			return fmt.Errorf("no heartbeat for %s", age.Round(time.Second))
+		}
+		return nil
+	}
+}
+
+// Flag - готовность однократного этапа запуска, например восстановления кэша
+type Flag struct {
+	done atomic.Bool
+}
+
+func (f *Flag) Set() {
+	f.done.Store(true)
+}
+
+func (f *Flag) Check(pending string) CheckFunc {
+	return func(context.Context) error {
+		if !f.done.Load() {
+			return errors.New(pending)
+		}
+		return nil
+	}
+}
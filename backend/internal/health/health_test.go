@@ -0,0 +1,82 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestChecker_Handler(t *testing.T) {
+	var restored Flag
+	var heartbeat Heartbeat
+
+	checker := NewChecker(time.Second)
+	checker.Add("database", func(context.Context) error { return nil })
+	checker.Add("cache", restored.Check("restore in progress"))
+	checker.Add("consumer", heartbeat.Check(time.Minute))
+
+	get := func() (int, Report) {
+		rec := httptest.NewRecorder()
+		checker.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		var report Report
+		if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+			t.Fatalf("decode report: %v", err)
+		}
+		return rec.Code, report
+	}
+
+	code, report := get()
+	if code != http.StatusServiceUnavailable || report.Status != StatusDown {
+		t.Errorf("before start: %d %s, want 503 down", code, report.Status)
+	}
+	if report.Checks["database"].Status != StatusUp {
+		t.Errorf("database = %+v, want up", report.Checks["database"])
+	}
+	for _, name := range []string{"cache", "consumer"} {
+		if r := report.Checks[name]; r.Status != StatusDown || r.Error == "" {
+			t.Errorf("%s = %+v, want down with error", name, r)
+		}
+	}
+
+	restored.Set()
+	heartbeat.Beat()
+	code, report = get()
+	if code != http.StatusOK || report.Status != StatusUp {
+		t.Errorf("after start: %d %s, want 200 up: %+v", code, report.Status, report.Checks)
+	}
+
+	heartbeat.Stop()
+	if _, report = get(); report.Checks["consumer"].Status != StatusDown {
+		t.Error("consumer check passed after heartbeat stopped")
+	}
+}
+
+func TestChecker_Timeout(t *testing.T) {
+	checker := NewChecker(10 * time.Millisecond)
+	checker.Add("kafka", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	report := checker.Run(context.Background())
+	if r := report.Checks["kafka"]; r.Status != StatusDown {
+		t.Errorf("kafka = %+v, want down after timeout", r)
+	}
+}
+
+func TestHeartbeat_Stale(t *testing.T) {
+	var heartbeat Heartbeat
+	heartbeat.last.Store(time.Now().Add(-time.Hour).UnixNano())
+
+	if err := heartbeat.Check(time.Minute)(context.Background()); err == nil {
+		t.Error("stale heartbeat check passed")
+	}
+
+	// nil heartbeat допустим и ничего не делает
+	var missing *Heartbeat
+	missing.Beat()
+	missing.Stop()
+}
@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// New создает логгер с уровнем debug|info|warn|error и форматом text|json
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithLevel(w, lvl, format)
+}
+
+// NewWithLevel создает логгер с внешним уровнем: с *slog.LevelVar уровень можно менять на лету
+func NewWithLevel(w io.Writer, level slog.Leveler, format string) (*slog.Logger, error) {
+	const op = "logger.NewWithLevel"
+
+	opts := &slog.HandlerOptions{Level: level}
+
+	switch strings.ToLower(format) {
+	case FormatText, "":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("%s: unknown format %q", op, format)
+}
+
+// ParseLevel разбирает уровень debug|info|warn|error
+func ParseLevel(level string) (slog.Level, error) {
+	const op = "logger.ParseLevel"
+
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return 0, fmt.Errorf("%s: unknown level %q", op, level)
+	}
+	return lvl, nil
+}
+
+// Err - атрибут с текстом ошибки
+func Err(err error) slog.Attr {
+	if err == nil {
+		return slog.String("error", "")
+	}
+	return slog.String("error", err.Error())
+}
@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		level   string
+		format  string
+		wantErr bool
+	}{
+		{name: "text info", level: "info", format: "text"},
+		{name: "json debug", level: "DEBUG", format: "json"},
+		{name: "unknown level", level: "verbose", format: "text", wantErr: true},
+		{name: "unknown format", level: "info", format: "xml", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(&bytes.Buffer{}, tt.level, tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNew_JSONRecord(t *testing.T) {
+	var buf bytes.Buffer
+	log, err := New(&buf, "info", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log.Debug("hidden")
+	log.Info("stored", "topic", "json_data", "order_uid", "b563feb7b2b84b6test", Err(errors.New("boom")))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("got %d records, want 1: %q", len(lines), buf.String())
+	}
+	var record map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("record is not JSON: %v", err)
+	}
+	for key, want := range map[string]string{"msg": "stored", "topic": "json_data", "order_uid": "b563feb7b2b84b6test", "error": "boom"} {
+		if record[key] != want {
+			t.Errorf("%s = %v, want %q", key, record[key], want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "order_service"
+
+var (
+	// Сообщения, прочитанные из kafka, по топикам
+	MessagesConsumed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_consumed_total",
+		Help:      "Messages read from Kafka, by topic.",
+	}, []string{"topic"})
+
+	// Сообщения, которые не удалось обработать, по топику и этапу (decode, validate, store)
+	MessageFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "message_failures_total",
+		Help:      "Messages that failed processing, by topic and stage.",
+	}, []string{"topic", "stage"})
+
+	// Обращения к кэшу заказов: hit или miss
+	CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Order cache lookups, by result (hit or miss).",
+	}, []string{"result"})
+
+	CacheEvictions = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_evictions_total",
+		Help:      "Orders evicted from the cache by the eviction policy.",
+	})
+
+	// Длительность запросов к хранилищу по методам
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Storage query duration, by query.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"query"})
+
+	// Длительность записи в kafka (ответы order_response и dead-letter сообщения)
+	KafkaWriteDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "kafka_write_duration_seconds",
+		Help:      "Kafka write latency, by topic.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"topic"})
+
+	KafkaWriteErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kafka_write_errors_total",
+		Help:      "Failed Kafka writes, by topic.",
+	}, []string{"topic"})
+
+	// Прочитанные сообщения, которые ждут в очередях обработчиков
+	ConsumerQueueLength = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "consumer_queue_length",
+		Help:      "Messages fetched from Kafka and waiting for a worker, by topic.",
+	}, []string{"topic"})
+
+	// Коммиты оффсетов консьюмерами: ok или error
+	OffsetCommits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "offset_commits_total",
+		Help:      "Consumer offset commits, by topic and result (ok or error).",
+	}, []string{"topic", "result"})
+)
+
+// Handler отдает метрики в формате Prometheus
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveQuery записывает длительность запроса к хранилищу, вызывается через defer:
+//
+//	defer metrics.ObserveQuery("get_order_by_uid", time.Now())
+func ObserveQuery(query string, start time.Time) {
+	DBQueryDuration.WithLabelValues(query).Observe(time.Since(start).Seconds())
+}
+
+// ObserveWrite записывает длительность записи в топик и, при ошибке, счетчик ошибок
+func ObserveWrite(topic string, start time.Time, err error) {
+	KafkaWriteDuration.WithLabelValues(topic).Observe(time.Since(start).Seconds())
+	if err != nil {
+		KafkaWriteErrors.WithLabelValues(topic).Inc()
+	}
+}
@@ -86,3 +86,61 @@ type Item struct {
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
+
+// Сообщение топика order_status: новый статус товара rid или, если rid пустой, всех товаров заказа
+type StatusUpdate struct {
+	OrderUID string `json:"order_uid"`
+	RID      string `json:"rid,omitempty"`
+	Status   int    `json:"status"`
+}
+
+// Способ удаления заказа, значение erasure.mode
+const (
+	// Заказ удаляется вместе с Delivery, Payment и Items
+	ErasureDelete = "delete"
+	// Заказ остается, персональные данные (customer_id и контакты доставки) очищаются
+	ErasureAnonymize = "anonymize"
+)
+
+// Источник запроса на удаление
+const (
+	ErasureSourceKafka = "kafka"
+	ErasureSourceHTTP  = "http"
+)
+
+// Сообщение топика order_erasure: запрос на удаление персональных данных заказа
+type ErasureRequest struct {
+	OrderUID string `json:"order_uid"`
+	// Кто запросил удаление: номер обращения или оператор, попадает в журнал удалений
+	RequestedBy string `json:"requested_by,omitempty"`
+}
+
+// Запись журнала удалений. Персональных данных не содержит
+type ErasureAudit struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	OrderUID    string    `gorm:"size:255;not null;index" json:"order_uid"`
+	Mode        string    `gorm:"size:20;not null" json:"mode"`
+	Source      string    `gorm:"size:20;not null" json:"source"`
+	RequestedBy string    `gorm:"size:255" json:"requested_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (ErasureAudit) TableName() string {
+	return "order_erasures"
+}
+
+// Статус ответа на запрос заказа
+type ResponseStatus string
+
+const (
+	StatusOK       ResponseStatus = "ok"
+	StatusNotFound ResponseStatus = "not_found"
+	StatusError    ResponseStatus = "error"
+)
+
+// Конверт ответа в топик order_response
+type OrderResponse struct {
+	Status ResponseStatus `json:"status"`
+	Order  *Order         `json:"order,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
@@ -0,0 +1,38 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"test/internal/models"
+	"test/internal/storage"
+)
+
+// SetErasureMode задает способ удаления заказов: models.ErasureDelete (по умолчанию)
+// или models.ErasureAnonymize. Вызывается до начала обработки запросов
+func (s *Service) SetErasureMode(mode string) {
+	s.erasureMode = mode
+}
+
+// EraseOrder удаляет или обезличивает заказ, пишет запись в журнал удалений и убирает
+// заказ из кэша и его файлов. Из кэша заказ убирается и тогда, когда его уже нет в БД:
+// повторный запрос мог прийти после падения между записью в БД и очисткой кэша
+func (s *Service) EraseOrder(ctx context.Context, req models.ErasureRequest, source string) (*models.ErasureAudit, error) {
+	const op = "orders.EraseOrder"
+
+	audit := &models.ErasureAudit{
+		OrderUID:    req.OrderUID,
+		Mode:        s.erasureMode,
+		Source:      source,
+		RequestedBy: req.RequestedBy,
+	}
+	err := s.storage.EraseOrder(ctx, audit)
+	if err != nil && !errors.Is(err, storage.ErrOrderNotFound) {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	s.cache.Delete(req.OrderUID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s: %w", op, req.OrderUID, ErrOrderNotFound)
+	}
+	return audit, nil
+}
@@ -0,0 +1,98 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"test/internal/metrics"
+	"test/internal/models"
+	"test/internal/storage"
+)
+
+var (
+	// ErrOrderNotFound возвращается, если заказа нет ни в кэше, ни в БД
+	ErrOrderNotFound = errors.New("order not found")
+	// ErrOrderRejected возвращается, если хранилище отвергло заказ: повтор записи не поможет
+	ErrOrderRejected = errors.New("order rejected by storage")
+	// ErrInvalidSearch возвращается при неверной сортировке или курсоре поиска
+	ErrInvalidSearch = errors.New("invalid search query")
+)
+
+// OrderCache - часть кэша, необходимая сервису заказов
+type OrderCache interface {
+	Get(key string) (models.Order, bool)
+	Peek(key string) (models.Order, bool)
+	Set(key string, val models.Order)
+	Update(key string, fn func(models.Order) models.Order) bool
+	Delete(key string) bool
+}
+
+// Service объединяет кэш и хранилище для чтения и записи заказов
+type Service struct {
+	cache    OrderCache
+	storage  storage.OrderStore
+	statuses *StatusMachine
+	// Способ удаления заказов, см. SetErasureMode
+	erasureMode string
+}
+
+func NewService(cache OrderCache, store storage.OrderStore, statuses *StatusMachine) *Service {
+	return &Service{
+		cache:       cache,
+		storage:     store,
+		statuses:    statuses,
+		erasureMode: models.ErasureDelete,
+	}
+}
+
+// GetOrder ищет заказ сначала в кэше, затем в БД
+func (s *Service) GetOrder(ctx context.Context, orderUID string) (*models.Order, error) {
+	const op = "orders.GetOrder"
+
+	if order, ok := s.cache.Get(orderUID); ok {
+		metrics.CacheRequests.WithLabelValues("hit").Inc()
+		return &order, nil
+	}
+	metrics.CacheRequests.WithLabelValues("miss").Inc()
+
+	order, err := s.storage.GetOrderByUID(ctx, orderUID)
+	if err != nil {
+		if errors.Is(err, storage.ErrOrderNotFound) {
+			return nil, fmt.Errorf("%s: %s: %w", op, orderUID, ErrOrderNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return order, nil
+}
+
+// SearchOrders ищет заказы в БД, кэш не используется: он хранит заказы только по order_uid
+func (s *Service) SearchOrders(ctx context.Context, query storage.SearchQuery) (*storage.SearchResult, error) {
+	const op = "orders.SearchOrders"
+
+	if err := query.Normalize(); err != nil {
+		return nil, fmt.Errorf("%s: %w: %v", op, ErrInvalidSearch, err)
+	}
+	result, err := s.storage.SearchOrders(ctx, query)
+	if err != nil {
+		if errors.Is(err, storage.ErrInvalidCursor) {
+			return nil, fmt.Errorf("%s: %w: %v", op, ErrInvalidSearch, err)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return result, nil
+}
+
+// SaveOrder записывает заказ в БД и, после успешной записи, в кэш
+func (s *Service) SaveOrder(ctx context.Context, order *models.Order) error {
+	const op = "orders.SaveOrder"
+
+	if err := s.storage.NewDataLoad(ctx, order); err != nil {
+		if errors.Is(err, storage.ErrRejected) {
+			return fmt.Errorf("%s: %w: %v", op, ErrOrderRejected, err)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	s.cache.Set(order.OrderUID, *order)
+	return nil
+}
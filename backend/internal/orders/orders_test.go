@@ -0,0 +1,141 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"test/internal/models"
+	"test/internal/storage/cache"
+	"test/internal/storage/memory"
+	"testing"
+)
+
+func TestService(t *testing.T) {
+	store := memory.New()
+	orderCache := cache.NewFifoCache(2)
+	svc := NewService(orderCache, store, NewStatusMachine(nil))
+	ctx := context.Background()
+
+	if _, err := svc.GetOrder(ctx, "b563feb7b2b84b6test"); !errors.Is(err, ErrOrderNotFound) {
+		t.Fatalf("GetOrder() error = %v, want ErrOrderNotFound", err)
+	}
+
+	if err := svc.SaveOrder(ctx, &models.Order{OrderUID: "b563feb7b2b84b6test", TrackNumber: "FIRST"}); err != nil {
+		t.Fatalf("SaveOrder() error = %v", err)
+	}
+	if err := svc.SaveOrder(ctx, &models.Order{OrderUID: "b563feb7b2b84b6test", TrackNumber: "SECOND"}); err != nil {
+		t.Fatalf("SaveOrder() upsert error = %v", err)
+	}
+
+	// После повторной записи кэш содержит новую версию
+	cached, ok := orderCache.Get("b563feb7b2b84b6test")
+	if !ok || cached.TrackNumber != "SECOND" {
+		t.Errorf("cached order = %+v, %v; want SECOND", cached, ok)
+	}
+
+	// Заказ, которого нет в кэше, читается из хранилища
+	orderCache.Delete("b563feb7b2b84b6test")
+	order, err := svc.GetOrder(ctx, "b563feb7b2b84b6test")
+	if err != nil || order.TrackNumber != "SECOND" {
+		t.Errorf("GetOrder() = %+v, %v", order, err)
+	}
+}
+
+func TestService_UpdateStatus(t *testing.T) {
+	store := memory.New()
+	orderCache := cache.NewFifoCache(2)
+	svc := NewService(orderCache, store, NewStatusMachine(map[int][]int{202: {203}, 203: {204}}))
+	ctx := context.Background()
+
+	order := &models.Order{OrderUID: "cached", Items: []models.Item{{RID: "r1", Status: 202}, {RID: "r2", Status: 202}}}
+	if err := svc.SaveOrder(ctx, order); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.NewDataLoad(ctx, &models.Order{OrderUID: "cold", Items: []models.Item{{RID: "r3", Status: 202}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Один товар: запись в кэше обновляется
+	if err := svc.UpdateStatus(ctx, models.StatusUpdate{OrderUID: "cached", RID: "r1", Status: 203}); err != nil {
+		t.Fatalf("UpdateStatus() error = %v", err)
+	}
+	cached, _ := orderCache.Peek("cached")
+	if cached.Items[0].Status != 203 || cached.Items[1].Status != 202 {
+		t.Errorf("cached statuses = %d, %d; want 203, 202", cached.Items[0].Status, cached.Items[1].Status)
+	}
+
+	// Весь заказ: переход 203 -> 203 для r1 запрещен, поэтому не меняется и r2
+	err := svc.UpdateStatus(ctx, models.StatusUpdate{OrderUID: "cached", Status: 203})
+	if !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("UpdateStatus() error = %v, want ErrInvalidTransition", err)
+	}
+	stored, _ := store.GetOrderByUID(ctx, "cached")
+	if stored.Items[1].Status != 202 {
+		t.Errorf("r2 status = %d, want unchanged 202", stored.Items[1].Status)
+	}
+
+	// Заказа нет в кэше: он обновляется только в хранилище
+	if err := svc.UpdateStatus(ctx, models.StatusUpdate{OrderUID: "cold", Status: 203}); err != nil {
+		t.Fatalf("UpdateStatus() error = %v", err)
+	}
+	if _, ok := orderCache.Peek("cold"); ok {
+		t.Error("status update added order to cache")
+	}
+
+	if err := svc.UpdateStatus(ctx, models.StatusUpdate{OrderUID: "missing", Status: 203}); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("unknown order: error = %v, want ErrOrderNotFound", err)
+	}
+	if err := svc.UpdateStatus(ctx, models.StatusUpdate{OrderUID: "cached", RID: "r9", Status: 203}); !errors.Is(err, ErrItemNotFound) {
+		t.Errorf("unknown rid: error = %v, want ErrItemNotFound", err)
+	}
+}
+
+func TestService_EraseOrder(t *testing.T) {
+	store := memory.New()
+	orderCache := cache.NewFifoCache(2)
+	svc := NewService(orderCache, store, NewStatusMachine(nil))
+	ctx := context.Background()
+
+	for _, uid := range []string{"a", "b"} {
+		order := &models.Order{OrderUID: uid, CustomerID: "test", Delivery: models.Delivery{Name: "Test Testov", Email: "test@gmail.com"}}
+		if err := svc.SaveOrder(ctx, order); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	audit, err := svc.EraseOrder(ctx, models.ErasureRequest{OrderUID: "a", RequestedBy: "ticket-1"}, models.ErasureSourceHTTP)
+	if err != nil {
+		t.Fatalf("EraseOrder() error = %v", err)
+	}
+	if audit.Mode != models.ErasureDelete || audit.Source != models.ErasureSourceHTTP || audit.RequestedBy != "ticket-1" {
+		t.Errorf("audit = %+v", audit)
+	}
+	if _, ok := orderCache.Peek("a"); ok {
+		t.Error("erased order is still cached")
+	}
+	if _, err := store.GetOrderByUID(ctx, "a"); err == nil {
+		t.Error("erased order is still stored")
+	}
+
+	// Обезличенный заказ остается в БД без персональных данных
+	svc.SetErasureMode(models.ErasureAnonymize)
+	if _, err := svc.EraseOrder(ctx, models.ErasureRequest{OrderUID: "b"}, models.ErasureSourceKafka); err != nil {
+		t.Fatalf("EraseOrder() anonymize error = %v", err)
+	}
+	if _, ok := orderCache.Peek("b"); ok {
+		t.Error("anonymized order is still cached")
+	}
+	stored, err := store.GetOrderByUID(ctx, "b")
+	if err != nil {
+		t.Fatalf("anonymized order: %v", err)
+	}
+	if stored.CustomerID != "" || stored.Delivery.Name != "" || stored.Delivery.Email != "" {
+		t.Errorf("anonymized order keeps personal data: %+v", stored)
+	}
+
+	if _, err := svc.EraseOrder(ctx, models.ErasureRequest{OrderUID: "a"}, models.ErasureSourceKafka); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("repeated erase: error = %v, want ErrOrderNotFound", err)
+	}
+	if audits := store.Audits(); len(audits) != 2 || audits[1].Mode != models.ErasureAnonymize {
+		t.Errorf("audits = %+v, want delete and anonymize records", audits)
+	}
+}
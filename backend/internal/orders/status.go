@@ -0,0 +1,68 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"test/internal/models"
+	"test/internal/storage"
+)
+
+var (
+	// ErrItemNotFound возвращается, если в заказе нет товара с указанным rid
+	ErrItemNotFound = errors.New("item not found")
+	// ErrInvalidTransition возвращается, если переход статуса не разрешен машиной состояний
+	ErrInvalidTransition = errors.New("invalid status transition")
+)
+
+// StatusMachine - разрешенные переходы статусов товаров. Статус, из которого
+// переходов нет, конечный
+type StatusMachine struct {
+	next map[int]map[int]bool
+}
+
+// NewStatusMachine строит машину состояний из списка переходов: статус -> допустимые следующие
+func NewStatusMachine(transitions map[int][]int) *StatusMachine {
+	m := &StatusMachine{next: make(map[int]map[int]bool, len(transitions))}
+	for from, targets := range transitions {
+		m.next[from] = make(map[int]bool, len(targets))
+		for _, to := range targets {
+			m.next[from][to] = true
+		}
+	}
+	return m
+}
+
+// Check возвращает ErrInvalidTransition, если из статуса from нельзя перейти в to
+func (m *StatusMachine) Check(from, to int) error {
+	if !m.next[from][to] {
+		return fmt.Errorf("%w: %d -> %d", ErrInvalidTransition, from, to)
+	}
+	return nil
+}
+
+// UpdateStatus меняет статус товара или всех товаров заказа, если переход разрешен для каждого из них.
+// Запись в кэше обновляется, только если заказ уже в нем: обновление статуса старого заказа
+// не должно вытеснять из кэша часто запрашиваемые
+func (s *Service) UpdateStatus(ctx context.Context, update models.StatusUpdate) error {
+	const op = "orders.UpdateStatus"
+
+	check := func(from int) error {
+		return s.statuses.Check(from, update.Status)
+	}
+	order, err := s.storage.UpdateItemStatus(ctx, update.OrderUID, update.RID, update.Status, check)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrOrderNotFound):
+			return fmt.Errorf("%s: %s: %w", op, update.OrderUID, ErrOrderNotFound)
+		case errors.Is(err, storage.ErrItemNotFound):
+			return fmt.Errorf("%s: %s: rid %q: %w", op, update.OrderUID, update.RID, ErrItemNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Проверка наличия и запись атомарны: заказ, удаленный из кэша параллельно
+	// (см. EraseOrder), не вернется в него
+	s.cache.Update(order.OrderUID, func(models.Order) models.Order { return *order })
+	return nil
+}
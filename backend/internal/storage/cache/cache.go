@@ -1,137 +1,229 @@
 package cache
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"fmt"
+	"log/slog"
 	"os"
-	"test/internal/config"
+	"path/filepath"
+	"sync"
+	"test/internal/logger"
+	"test/internal/metrics"
 	"test/internal/models"
-	"test/internal/storage/postgres"
+	"test/internal/storage"
 )
 
-type Cache map[string]models.Order
+// Политики вытеснения, значение cache_params.policy
+const (
+	PolicyFIFO = "fifo"
+	PolicyLRU  = "lru"
+	PolicyLFU  = "lfu"
+)
+
+type Data map[string]models.Order
 
-type FifoCache struct {
-	capacity int
-	data     Cache
-	ring     []string
-	pos      int
-	size     int
-	alive    map[string]bool
+// Cache - общий интерфейс кэшей заказов. Реализации безопасны для
+// одновременного использования из нескольких горутин
+type Cache interface {
+	Get(key string) (models.Order, bool)
+	// Peek читает значение, не меняя порядок вытеснения и частоту обращений
+	Peek(key string) (models.Order, bool)
+	Set(key string, val models.Order)
+	// Update заменяет значение на fn(текущее), только если ключ есть в кэше. Проверка
+	// и запись выполняются под блокировкой кэша, порядок вытеснения не меняется
+	Update(key string, fn func(models.Order) models.Order) bool
+	Delete(key string) bool
+	Len() int
+	// Keys возвращает ключи в порядке вытеснения: первый ключ будет вытеснен первым
+	Keys() []string
+	// Resize меняет вместимость. При уменьшении лишние ключи вытесняются в порядке
+	// вытеснения политики: FIFO оставляет последние добавленные, LRU - последние
+	// использованные, а LFU - самые часто читаемые, даже если среди вытесненных
+	// есть только что добавленные заказы
+	Resize(capacity int)
+	// OnEvict задает обработчик вытеснения политикой (не Delete). Задается до
+	// начала использования кэша, вызывается под его блокировкой
+	OnEvict(fn func(key string))
 }
 
-// TODO: Переписать функцию так чтобы был вариант востановления кжша
-func NewFifoCache(capacity int) *FifoCache {
-	return &FifoCache{
-		capacity: capacity,
-		data:     make(Cache, capacity),
-		ring:     make([]string, capacity),
-		alive:    make(map[string]bool, capacity),
-	}
+// evictHook - общая часть реализаций: метрика вытеснений и обработчик OnEvict
+type evictHook struct {
+	onEvict func(key string)
 }
 
-// Востановление кэша
-func RestoreCache(path string, capacity int, storage *postgres.Storage) *FifoCache {
-	const op = "storage.cache.RestoreCache"
-	uids, err := LoadCacheMetaData(path)
-	if err != nil {
-		log.Println("Loc: %s, Err: %v", op, err)
-		return nil
+func (h *evictHook) OnEvict(fn func(key string)) {
+	h.onEvict = fn
+}
+
+func (h *evictHook) evicted(key string) {
+	metrics.CacheEvictions.Inc()
+	if h.onEvict != nil {
+		h.onEvict(key)
 	}
-	orders, err := storage.GetDataToRestoreCache(uids)
-	if err != nil {
-		log.Println("Loc: %s, Err: %v", op, err)
-		return nil
+}
+
+// New создает пустой кэш с выбранной политикой вытеснения
+func New(policy string, capacity int) (Cache, error) {
+	const op = "storage.cache.New"
+
+	if capacity <= 0 {
+		return nil, fmt.Errorf("%s: capacity must be positive, got %d", op, capacity)
 	}
-	cache := &FifoCache{
-		capacity: capacity,
-		data:     orders,
-		ring:     make([]string, capacity),
-		alive:    make(map[string]bool, capacity),
+	switch policy {
+	case PolicyFIFO, "":
+		return NewFifoCache(capacity), nil
+	case PolicyLRU:
+		return NewLRUCache(capacity), nil
+	case PolicyLFU:
+		return NewLFUCache(capacity), nil
 	}
-	i := 0
-	for uid := range orders {
-		cache.ring[i] = uid
-		cache.alive[uid] = true
-		i++
+	return nil, fmt.Errorf("%s: unknown eviction policy %q", op, policy)
+}
+
+// Режимы сохранения кэша, значение cache_params.mode
+const (
+	// В файл пишется только список ключей, значения при старте читаются из БД
+	ModeKeys = "keys"
+	// В файл пишется снимок с заказами, кэш восстанавливается без БД
+	ModeSnapshot = "snapshot"
+)
+
+// PersistentCache после каждого изменения сохраняет список ключей или снимок в path,
+// чтобы после перезапуска кэш можно было восстановить
+type PersistentCache struct {
+	Cache
+	log      *slog.Logger
+	mu       sync.Mutex
+	path     string
+	snapshot bool
+}
+
+func NewPersistent(log *slog.Logger, c Cache, path string) *PersistentCache {
+	return &PersistentCache{
+		Cache: c,
+		log:   log,
+		path:  path,
 	}
-	log.Println("Cache is loaded")
-	return cache
 }
 
-func (c *FifoCache) Set(cfg *config.Config, key string, val models.Order) {
-	if _, ok := c.data[key]; ok {
-		c.data[key] = val
+// NewSnapshotPersistent сохраняет полный снимок кэша, см. SaveSnapshot
+func NewSnapshotPersistent(log *slog.Logger, c Cache, path string) *PersistentCache {
+	p := NewPersistent(log, c, path)
+	p.snapshot = true
+	return p
+}
 
-		err := SaveCacheMetaData(cfg.CacheParams.Path, c.data)
-		if err != nil {
-			log.Println("Troubles with saving restore cache metadata")
-		}
-		return
-	}
-	if c.size < c.capacity {
-		c.data[key] = val
-		c.ring[c.pos] = key
-		c.alive[key] = true
-		c.pos = (c.pos + 1) % c.capacity
-		c.size++
-
-		// Снова попытка записи
-		err := SaveCacheMetaData(cfg.CacheParams.Path, c.data)
-		if err != nil {
-			log.Println("Troubles with saving restore cache metadata")
-		}
-		return
+func (p *PersistentCache) Set(key string, val models.Order) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.Cache.Set(key, val)
+	p.save()
+}
+
+func (p *PersistentCache) Update(key string, fn func(models.Order) models.Order) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.Cache.Update(key, fn) {
+		return false
 	}
-	oldKey := c.ring[c.pos]
-	if c.alive[oldKey] {
-		delete(c.data, oldKey)
-		delete(c.alive, oldKey)
+	p.save()
+	return true
+}
+
+func (p *PersistentCache) Delete(key string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.Cache.Delete(key) {
+		return false
 	}
-	c.data[key] = val
-	c.ring[c.pos] = key
-	c.alive[key] = true
-	c.pos = (c.pos + 1) % c.capacity
+	p.save()
+	return true
+}
+
+func (p *PersistentCache) Resize(capacity int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.Cache.Resize(capacity)
+	p.save()
+}
 
-	// Сохранение в json нового набора ключей
-	err := SaveCacheMetaData(cfg.CacheParams.Path, c.data)
+func (p *PersistentCache) save() {
+	// Сохранение в json нового набора ключей или снимка
+	var err error
+	if p.snapshot {
+		err = SaveSnapshot(p.path, p.Cache)
+	} else {
+		err = SaveCacheMetaData(p.path, p.Cache.Keys())
+	}
 	if err != nil {
-		log.Println("Troubles with saving restore cache metadata")
+		p.log.Warn("failed to save cache metadata", slog.String("path", p.path), logger.Err(err))
 	}
 }
 
-func (c *FifoCache) Get(key string) (models.Order, bool) {
-	v, ok := c.data[key]
-	log.Println("Кэш отдал данные")
-	return v, ok
-}
+// Востановление кэша: заказы из сохраненного списка ключей загружаются из БД
+// и добавляются в c в сохраненном порядке
+func RestoreCache(ctx context.Context, log *slog.Logger, c Cache, path string, store storage.OrderStore) error {
+	const op = "storage.cache.RestoreCache"
 
-func (c *FifoCache) Delete(key string) bool {
-	if _, ok := c.data[key]; !ok {
-		return false
+	uids, err := LoadCacheMetaData(path)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
-	delete(c.data, key)
-	if c.alive[key] {
-		delete(c.alive, key)
-		c.size--
+	orders, err := store.GetDataToRestoreCache(ctx, uids)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
-	return true
+	for _, uid := range uids {
+		if order, ok := orders[uid]; ok {
+			c.Set(uid, order)
+		}
+	}
+	log.Info("cache is restored", slog.Int("saved", len(uids)), slog.Int("loaded", c.Len()))
+	return nil
 }
 
 // Функции сохранения метаданных и востановления
 
-func SaveCacheMetaData(path string, data Cache) error {
-	var uids = []string{}
-	// Сохраним все доступные uid параметры
-	for uid := range data {
-		uids = append(uids, uid)
+func SaveCacheMetaData(path string, uids []string) error {
+	if uids == nil {
+		uids = []string{}
 	}
-
 	bytes, err := json.Marshal(uids)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, bytes, 0644)
+	return writeFileAtomic(path, bytes)
+}
+
+// writeFileAtomic пишет во временный файл рядом с path и переименовывает его,
+// чтобы при падении на середине записи остался предыдущий файл целиком
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
 }
 
 // Востановление кэша из бд по сохроненным метаданным
@@ -0,0 +1,268 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"test/internal/models"
+	"test/internal/storage/memory"
+	"testing"
+)
+
+func order(uid string) models.Order {
+	return models.Order{OrderUID: uid}
+}
+
+func TestNew(t *testing.T) {
+	for _, policy := range []string{PolicyFIFO, PolicyLRU, PolicyLFU} {
+		if _, err := New(policy, 2); err != nil {
+			t.Errorf("New(%q) error = %v", policy, err)
+		}
+	}
+	if _, err := New("random", 2); err == nil {
+		t.Error("New(random) expected error")
+	}
+	if _, err := New(PolicyFIFO, 0); err == nil {
+		t.Error("New with zero capacity expected error")
+	}
+}
+
+func TestEvictionPolicies(t *testing.T) {
+	tests := []struct {
+		policy   string
+		wantKeys []string
+	}{
+		// a добавлен первым и вытесняется, несмотря на чтение
+		{policy: PolicyFIFO, wantKeys: []string{"b", "d"}},
+		// b дольше всего не использовался
+		{policy: PolicyLRU, wantKeys: []string{"a", "d"}},
+		// у b меньше всего обращений
+		{policy: PolicyLFU, wantKeys: []string{"d", "a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			c, err := New(tt.policy, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.Set("a", order("a"))
+			c.Set("b", order("b"))
+			c.Get("a")
+			c.Get("a")
+			c.Set("d", order("d"))
+
+			if got := c.Keys(); !reflect.DeepEqual(got, tt.wantKeys) {
+				t.Errorf("Keys() = %v, want %v", got, tt.wantKeys)
+			}
+			if c.Len() != 2 {
+				t.Errorf("Len() = %d, want 2", c.Len())
+			}
+			if !c.Delete(tt.wantKeys[0]) || c.Delete(tt.wantKeys[0]) {
+				t.Error("Delete should succeed once")
+			}
+			if c.Len() != 1 {
+				t.Errorf("Len() after Delete = %d, want 1", c.Len())
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	for _, policy := range []string{PolicyFIFO, PolicyLRU, PolicyLFU} {
+		t.Run(policy, func(t *testing.T) {
+			c, err := New(policy, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.Set("a", order("a"))
+			c.Set("b", order("b"))
+			want := c.Keys()
+
+			track := func(o models.Order) models.Order {
+				o.TrackNumber = "updated"
+				return o
+			}
+			if !c.Update("a", track) {
+				t.Error("Update() of a cached key = false")
+			}
+			if o, _ := c.Peek("a"); o.TrackNumber != "updated" {
+				t.Errorf("order a = %q, want updated", o.TrackNumber)
+			}
+			if got := c.Keys(); !reflect.DeepEqual(got, want) {
+				t.Errorf("Update() changed eviction order: Keys() = %v, want %v", got, want)
+			}
+			// Отсутствующий ключ не добавляется
+			if c.Update("missing", track) {
+				t.Error("Update() of a missing key = true")
+			}
+			if _, ok := c.Peek("missing"); ok || c.Len() != 2 {
+				t.Error("Update() added a missing key")
+			}
+		})
+	}
+}
+
+func TestFifoCache_DeleteThenSet(t *testing.T) {
+	c := NewFifoCache(2)
+
+	c.Set("a", order("a"))
+	c.Set("b", order("b"))
+	c.Set("a", models.Order{OrderUID: "a", TrackNumber: "updated"})
+	c.Set("c", order("c"))
+
+	if _, ok := c.Get("a"); ok {
+		t.Error("oldest key a should be evicted")
+	}
+	if !c.Delete("b") {
+		t.Fatal("Delete(b) = false")
+	}
+	c.Set("b", order("b"))
+	c.Set("d", order("d"))
+	if len(c.data) > c.capacity {
+		t.Errorf("cache holds %d entries, capacity %d", len(c.data), c.capacity)
+	}
+}
+
+func TestFifoCache_DeleteKeepsRoom(t *testing.T) {
+	c := NewFifoCache(3)
+	for _, uid := range []string{"a", "b", "c"} {
+		c.Set(uid, order(uid))
+	}
+	c.Delete("b")
+	// Место b свободно: d не вытесняет a
+	c.Set("d", order("d"))
+	if c.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", c.Len())
+	}
+	if got, want := c.Keys(), []string{"a", "c", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys() = %v, want %v", got, want)
+	}
+	// Следующим вытесняется самый старый ключ
+	c.Set("e", order("e"))
+	if got, want := c.Keys(), []string{"c", "d", "e"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after Set Keys() = %v, want %v", got, want)
+	}
+}
+
+func TestResize(t *testing.T) {
+	for _, policy := range []string{PolicyFIFO, PolicyLRU, PolicyLFU} {
+		t.Run(policy, func(t *testing.T) {
+			c, err := New(policy, 4)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, uid := range []string{"a", "b", "c", "d"} {
+				c.Set(uid, order(uid))
+			}
+
+			// При уменьшении остаются два самых свежих ключа
+			c.Resize(2)
+			if got, want := c.Keys(), []string{"c", "d"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("Keys() after shrink = %v, want %v", got, want)
+			}
+			if _, ok := c.Get("a"); ok {
+				t.Error("a should be evicted by Resize")
+			}
+
+			// После увеличения новые ключи не вытесняют старые, пока есть место
+			c.Resize(3)
+			c.Set("e", order("e"))
+			if c.Len() != 3 {
+				t.Errorf("Len() after grow = %d, want 3", c.Len())
+			}
+			c.Set("f", order("f"))
+			if c.Len() != 3 {
+				t.Errorf("Len() = %d, want 3", c.Len())
+			}
+		})
+	}
+}
+
+func TestPersistentCache_SavesKeysInOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	c := NewPersistent(slog.New(slog.DiscardHandler), NewFifoCache(3), path)
+
+	for _, uid := range []string{"a", "b", "c", "d"} {
+		c.Set(uid, order(uid))
+	}
+	c.Delete("c")
+
+	uids, err := LoadCacheMetaData(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"b", "d"}; !reflect.DeepEqual(uids, want) {
+		t.Errorf("saved keys = %v, want %v", uids, want)
+	}
+}
+
+func TestRestoreCache(t *testing.T) {
+	store := memory.New()
+	for _, uid := range []string{"a", "b", "c"} {
+		o := order(uid)
+		if err := store.NewDataLoad(context.Background(), &o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(t.TempDir(), "cache.json")
+	// Ключ missing уже удален из БД и при восстановлении пропускается
+	if err := SaveCacheMetaData(path, []string{"c", "missing", "a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewFifoCache(3)
+	if err := RestoreCache(context.Background(), slog.New(slog.DiscardHandler), c, path, store); err != nil {
+		t.Fatalf("RestoreCache() error = %v", err)
+	}
+	if got, want := c.Keys(), []string{"c", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys() = %v, want %v", got, want)
+	}
+
+	if err := RestoreCache(context.Background(), slog.New(slog.DiscardHandler), NewFifoCache(3), filepath.Join(t.TempDir(), "absent.json"), store); err == nil {
+		t.Error("RestoreCache() without metadata file expected error")
+	}
+}
+
+// Запускать с -race
+func TestCache_Concurrent(t *testing.T) {
+	for _, policy := range []string{PolicyFIFO, PolicyLRU, PolicyLFU} {
+		t.Run(policy, func(t *testing.T) {
+			c, err := New(policy, 8)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var wg sync.WaitGroup
+			for w := 0; w < 4; w++ {
+				wg.Add(3)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < 200; i++ {
+						key := fmt.Sprintf("uid-%d", i%16)
+						c.Set(key, order(key))
+					}
+				}()
+				go func() {
+					defer wg.Done()
+					for i := 0; i < 200; i++ {
+						c.Get(fmt.Sprintf("uid-%d", i%16))
+					}
+				}()
+				go func() {
+					defer wg.Done()
+					for i := 0; i < 200; i++ {
+						c.Delete(fmt.Sprintf("uid-%d", i%16))
+					}
+				}()
+			}
+			wg.Wait()
+
+			if n := c.Len(); n > 8 || n != len(c.Keys()) {
+				t.Errorf("Len() = %d, Keys() = %v", n, c.Keys())
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"sync"
+	"test/internal/models"
+)
+
+// FifoCache вытесняет ключи в порядке добавления
+type FifoCache struct {
+	mu       sync.RWMutex
+	capacity int
+	data     Data
+	ring     []string
+	pos      int
+	size     int
+	alive    map[string]bool
+	evictHook
+}
+
+func NewFifoCache(capacity int) *FifoCache {
+	return &FifoCache{
+		capacity: capacity,
+		data:     make(Data, capacity),
+		ring:     make([]string, capacity),
+		alive:    make(map[string]bool, capacity),
+	}
+}
+
+func (c *FifoCache) Set(key string, val models.Order) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Обновление существующего ключа не меняет его позицию в очереди
+	if _, ok := c.data[key]; !ok {
+		// Слот под курсором занят живым ключом только когда кэш заполнен,
+		// такой ключ вытесняется
+		oldKey := c.ring[c.pos]
+		if oldKey != "" && c.alive[oldKey] {
+			delete(c.data, oldKey)
+			delete(c.alive, oldKey)
+			c.evicted(oldKey)
+		} else {
+			c.size++
+		}
+		c.ring[c.pos] = key
+		c.alive[key] = true
+		c.pos = (c.pos + 1) % c.capacity
+	}
+	c.data[key] = val
+}
+
+func (c *FifoCache) Get(key string) (models.Order, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	v, ok := c.data[key]
+	return v, ok
+}
+
+func (c *FifoCache) Peek(key string) (models.Order, bool) {
+	return c.Get(key)
+}
+
+func (c *FifoCache) Update(key string, fn func(models.Order) models.Order) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v, ok := c.data[key]
+	if !ok {
+		return false
+	}
+	c.data[key] = fn(v)
+	return true
+}
+
+func (c *FifoCache) Delete(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.data[key]; !ok {
+		return false
+	}
+	delete(c.data, key)
+	delete(c.alive, key)
+	// Кольцо сжимается, как в Resize: свободные слоты оказываются под курсором,
+	// и следующий Set не вытесняет живой ключ, пока в кэше есть место
+	keys := c.keys()
+	clear(c.ring)
+	copy(c.ring, keys)
+	c.size = len(keys)
+	c.pos = c.size % c.capacity
+	return true
+}
+
+func (c *FifoCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.size
+}
+
+// Resize перестраивает кольцо: оставшиеся ключи занимают его начало в прежнем порядке
+func (c *FifoCache) Resize(capacity int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := c.keys()
+	if len(keys) > capacity {
+		for _, key := range keys[:len(keys)-capacity] {
+			delete(c.data, key)
+			delete(c.alive, key)
+			c.evicted(key)
+		}
+		keys = keys[len(keys)-capacity:]
+	}
+	c.capacity = capacity
+	c.ring = make([]string, capacity)
+	copy(c.ring, keys)
+	c.size = len(keys)
+	c.pos = c.size % capacity
+}
+
+// Keys обходит кольцо начиная с курсора - самого старого слота
+func (c *FifoCache) Keys() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.keys()
+}
+
+func (c *FifoCache) keys() []string {
+	keys := make([]string, 0, c.size)
+	for i := 0; i < c.capacity; i++ {
+		key := c.ring[(c.pos+i)%c.capacity]
+		if key != "" && c.alive[key] {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
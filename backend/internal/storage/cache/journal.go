@@ -0,0 +1,396 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"sync"
+	"test/internal/logger"
+	"test/internal/models"
+)
+
+// ModeJournal - изменения дописываются в журнал, который периодически сжимается в снимок
+const ModeJournal = "journal"
+
+// Операции журнала
+const (
+	opSet    = "set"
+	opDelete = "delete"
+	opEvict  = "evict"
+)
+
+type journalRecord struct {
+	Seq   uint64        `json:"seq"`
+	Op    string        `json:"op"`
+	Key   string        `json:"key"`
+	Order *models.Order `json:"order,omitempty"`
+}
+
+// JournalPath - путь журнала рядом со снимком
+func JournalPath(path string) string {
+	return path + ".journal"
+}
+
+// JournalCache дописывает каждое изменение кэша (set, delete и evict) одной строкой
+// в журнал, а после compactEvery записей сжимает журнал в снимок (см. SaveSnapshot).
+// Запись в журнал не зависит от размера кэша; восстановление повторяет операции
+// по порядку и воспроизводит кольцо FIFO точно. Чтения LRU и LFU не журналируются,
+// их порядок вытеснения точно сохраняется только в снимке
+type JournalCache struct {
+	Cache
+	log          *slog.Logger
+	mu           sync.Mutex
+	path         string
+	file         *os.File
+	w            *bufio.Writer
+	seq          uint64
+	records      int
+	compactEvery int
+	// Ключи, чьи заказы могут быть в файлах: снимок последнего сжатия и записи set после него
+	written map[string]bool
+	// После Close журнал не пишется и не сжимается, см. Close
+	closed bool
+}
+
+// NewJournal сжимает текущее содержимое c в снимок path и начинает новый журнал.
+// c не должен использоваться напрямую после вызова: вытеснения журналируются через OnEvict
+func NewJournal(log *slog.Logger, c Cache, path string, compactEvery int) (*JournalCache, error) {
+	const op = "storage.cache.NewJournal"
+
+	j := &JournalCache{
+		Cache:        c,
+		log:          log,
+		path:         path,
+		compactEvery: compactEvery,
+		written:      make(map[string]bool),
+	}
+	// Номер продолжает нумерацию предыдущего журнала, чтобы старые записи не применились повторно
+	if snapshot, err := LoadSnapshot(path); err == nil {
+		j.seq = snapshot.Seq
+	}
+	if seq, err := lastJournalSeq(JournalPath(path)); err == nil && seq > j.seq {
+		j.seq = seq
+	}
+	if err := j.compact(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	// Вызывается под j.mu: вытеснение происходит только внутри Set и Resize
+	c.OnEvict(func(key string) {
+		j.append(journalRecord{Op: opEvict, Key: key})
+	})
+	return j, nil
+}
+
+func (j *JournalCache) Set(key string, val models.Order) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.Cache.Set(key, val)
+	j.append(journalRecord{Op: opSet, Key: key, Order: &val})
+	j.markWritten([]string{key})
+	j.flush()
+}
+
+func (j *JournalCache) Update(key string, fn func(models.Order) models.Order) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var val models.Order
+	updated := j.Cache.Update(key, func(old models.Order) models.Order {
+		val = fn(old)
+		return val
+	})
+	if !updated {
+		return false
+	}
+	j.append(journalRecord{Op: opSet, Key: key, Order: &val})
+	j.markWritten([]string{key})
+	j.flush()
+	return true
+}
+
+// Delete кроме записи delete стирает данные заказа из снимка и прежних записей журнала
+// (см. scrub): удаленный заказ не должен оставаться в файлах до следующего сжатия.
+// Вытесненный заказ остается в журнале до сжатия, поэтому файлы очищаются и для ключа,
+// которого уже нет в кэше, но только если он записывался в них
+func (j *JournalCache) Delete(key string) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	deleted := j.Cache.Delete(key)
+	if j.closed {
+		// Журнала уже нет: удаленный заказ убирается из снимка перезаписью снимка
+		if j.written[key] {
+			if err := saveSnapshot(j.path, j.Cache, j.seq); err != nil {
+				j.log.Warn("failed to scrub cache files", slog.String("path", j.path), slog.String("key", key), logger.Err(err))
+				return deleted
+			}
+			delete(j.written, key)
+		}
+		return deleted
+	}
+	if deleted {
+		j.append(journalRecord{Op: opDelete, Key: key})
+		j.flush()
+	}
+	if !j.written[key] {
+		return deleted
+	}
+	if err := j.scrub(key); err != nil {
+		j.log.Warn("failed to scrub cache files", slog.String("path", j.path), slog.String("key", key), logger.Err(err))
+		return deleted
+	}
+	delete(j.written, key)
+	return deleted
+}
+
+func (j *JournalCache) Resize(capacity int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.Cache.Resize(capacity)
+	j.flush()
+}
+
+// Close сжимает журнал в снимок и закрывает файл. Изменения после Close остаются
+// только в памяти, кроме Delete: удаленный заказ стирается и из снимка
+func (j *JournalCache) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.closed {
+		return nil
+	}
+	err := j.compact()
+	if j.file != nil {
+		err = errors.Join(err, j.file.Close())
+	}
+	j.file, j.w = nil, nil
+	j.closed = true
+	return err
+}
+
+// scrub заменяет заказ key в снимке пустым, а в записях set журнала убирает заказ.
+// Ключи остаются на своих местах, чтобы восстановление повторило кольцо FIFO точно;
+// вызывается под j.mu
+func (j *JournalCache) scrub(key string) error {
+	if snapshot, err := LoadSnapshot(j.path); err == nil {
+		changed := false
+		for i := range snapshot.Entries {
+			if snapshot.Entries[i].Key == key {
+				snapshot.Entries[i].Order = models.Order{}
+				changed = true
+			}
+		}
+		if changed {
+			data, err := json.Marshal(snapshot)
+			if err != nil {
+				return err
+			}
+			if err := writeFileAtomic(j.path, data); err != nil {
+				return err
+			}
+		}
+	}
+	if j.w == nil || j.records == 0 {
+		return nil
+	}
+
+	journalPath := JournalPath(j.path)
+	data, err := os.ReadFile(journalPath)
+	if err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	changed := false
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		var rec journalRecord
+		// Недописанные и поврежденные строки переносятся как есть
+		if json.Unmarshal(line, &rec) == nil && rec.Op == opSet && rec.Key == key && rec.Order != nil {
+			rec.Order = nil
+			if encoded, err := json.Marshal(rec); err == nil {
+				out.Write(append(encoded, '\n'))
+				changed = true
+				continue
+			}
+		}
+		out.Write(line)
+	}
+	if !changed {
+		return nil
+	}
+	if err := writeFileAtomic(journalPath, out.Bytes()); err != nil {
+		return err
+	}
+	// Файл заменен, дальше дописываем в новый
+	j.file.Close()
+	file, err := os.OpenFile(journalPath, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		j.file, j.w = nil, nil
+		return err
+	}
+	j.file = file
+	j.w = bufio.NewWriter(file)
+	return nil
+}
+
+// append вызывается под j.mu
+func (j *JournalCache) append(rec journalRecord) {
+	if j.closed || j.w == nil {
+		return
+	}
+	j.seq++
+	rec.Seq = j.seq
+	data, err := json.Marshal(rec)
+	if err != nil {
+		j.log.Warn("failed to encode cache journal record", slog.String("key", rec.Key), logger.Err(err))
+		return
+	}
+	j.w.Write(append(data, '\n'))
+	j.records++
+}
+
+// flush дописывает буфер в файл и при необходимости сжимает журнал, вызывается под j.mu
+func (j *JournalCache) flush() {
+	if j.closed {
+		return
+	}
+	// Журнал не удалось открыть при прошлом сжатии: пробуем снова, снимок сохраняет текущее состояние
+	if j.w == nil {
+		if err := j.compact(); err != nil {
+			j.log.Warn("failed to compact cache journal", slog.String("path", j.path), logger.Err(err))
+		}
+		return
+	}
+	if err := j.w.Flush(); err != nil {
+		j.log.Warn("failed to write cache journal", slog.String("path", JournalPath(j.path)), logger.Err(err))
+	}
+	if j.compactEvery > 0 && j.records >= j.compactEvery {
+		if err := j.compact(); err != nil {
+			j.log.Warn("failed to compact cache journal", slog.String("path", j.path), logger.Err(err))
+		}
+	}
+}
+
+// compact пишет снимок с номером последней записи и начинает пустой журнал.
+// Если сервис упадет между этими шагами, записи старого журнала с номерами
+// не больше номера снимка при восстановлении пропускаются
+func (j *JournalCache) compact() error {
+	if j.closed {
+		return nil
+	}
+	if err := saveSnapshot(j.path, j.Cache, j.seq); err != nil {
+		return err
+	}
+	if j.file != nil {
+		j.file.Close()
+	}
+	file, err := os.OpenFile(JournalPath(j.path), os.O_CREATE|os.O_WRONLY|os.O_TRUNC|os.O_APPEND, 0644)
+	if err != nil {
+		// Старый журнал мог остаться, поэтому записанные ключи не сбрасываются
+		j.file, j.w = nil, nil
+		j.markWritten(j.Cache.Keys())
+		return err
+	}
+	j.file = file
+	j.w = bufio.NewWriter(file)
+	j.records = 0
+	// Снимок содержит ровно текущие ключи кэша, старые записи журнала удалены
+	j.written = make(map[string]bool, j.Cache.Len())
+	j.markWritten(j.Cache.Keys())
+	return nil
+}
+
+// markWritten вызывается под j.mu
+func (j *JournalCache) markWritten(keys []string) {
+	for _, key := range keys {
+		j.written[key] = true
+	}
+}
+
+// RestoreJournal заполняет c из снимка path и повторяет операции журнала после него.
+// Недописанная последняя строка (падение во время записи) пропускается
+func RestoreJournal(log *slog.Logger, c Cache, path string) error {
+	const op = "storage.cache.RestoreJournal"
+
+	snapshot, err := LoadSnapshot(path)
+	journal, journalErr := os.Open(JournalPath(path))
+	if err != nil && journalErr != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	var seq uint64
+	if snapshot != nil {
+		seq = snapshot.Seq
+		for _, entry := range snapshot.Entries {
+			c.Set(entry.Key, entry.Order)
+		}
+	}
+
+	replayed := 0
+	if journalErr == nil {
+		defer journal.Close()
+		replayed, err = replayJournal(log, c, journal, seq)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	} else if !errors.Is(journalErr, fs.ErrNotExist) {
+		return fmt.Errorf("%s: %w", op, journalErr)
+	}
+	log.Info("cache is restored from journal", slog.Int("replayed", replayed), slog.Int("loaded", c.Len()))
+	return nil
+}
+
+func replayJournal(log *slog.Logger, c Cache, journal *os.File, after uint64) (int, error) {
+	scanner := bufio.NewScanner(journal)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	replayed := 0
+	for scanner.Scan() {
+		var rec journalRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			log.Warn("skipping corrupt cache journal record", slog.Int("replayed", replayed), logger.Err(err))
+			continue
+		}
+		if rec.Seq <= after {
+			continue
+		}
+		switch rec.Op {
+		case opSet:
+			// Без заказа - запись удаленного потом заказа (см. scrub): ключ занимает
+			// свое место в кэше до записи delete
+			order := models.Order{}
+			if rec.Order != nil {
+				order = *rec.Order
+			}
+			c.Set(rec.Key, order)
+		case opDelete, opEvict:
+			c.Delete(rec.Key)
+		}
+		replayed++
+	}
+	return replayed, scanner.Err()
+}
+
+// lastJournalSeq возвращает номер последней читаемой записи журнала
+func lastJournalSeq(path string) (uint64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var last uint64
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var rec journalRecord
+		if json.Unmarshal(scanner.Bytes(), &rec) == nil && rec.Seq > last {
+			last = rec.Seq
+		}
+	}
+	return last, scanner.Err()
+}
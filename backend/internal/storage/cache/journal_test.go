@@ -0,0 +1,310 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"test/internal/models"
+	"testing"
+)
+
+func journalLines(t *testing.T, path string) int {
+	t.Helper()
+	file, err := os.Open(JournalPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	n := 0
+	for scanner := bufio.NewScanner(file); scanner.Scan(); {
+		n++
+	}
+	return n
+}
+
+func TestJournal_RestoresRingOrder(t *testing.T) {
+	log := slog.New(slog.DiscardHandler)
+	path := filepath.Join(t.TempDir(), "cache.json")
+
+	j, err := NewJournal(log, NewFifoCache(3), path, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, uid := range []string{"a", "b", "c", "d"} {
+		j.Set(uid, order(uid))
+	}
+	j.Delete("c")
+	j.Set("e", order("e"))
+	want := j.Keys()
+
+	// Изменения только дописываются: 5 set, 1 delete и 1 evict
+	// (d вытесняет a, e занимает место удаленного c)
+	if n := journalLines(t, path); n != 7 {
+		t.Errorf("journal has %d records, want 7", n)
+	}
+
+	// Без Close: восстановление по снимку и журналу, как после падения
+	restored := NewFifoCache(3)
+	if err := RestoreJournal(log, restored, path); err != nil {
+		t.Fatalf("RestoreJournal() error = %v", err)
+	}
+	if got := restored.Keys(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys() = %v, want %v", got, want)
+	}
+
+	// Следующая вставка вытесняет тот же ключ, что и в исходном кэше
+	j.Set("f", order("f"))
+	restored.Set("f", order("f"))
+	if got, want := restored.Keys(), j.Keys(); !reflect.DeepEqual(got, want) {
+		t.Errorf("after Set Keys() = %v, want %v", got, want)
+	}
+}
+
+func TestJournal_Update(t *testing.T) {
+	log := slog.New(slog.DiscardHandler)
+	path := filepath.Join(t.TempDir(), "cache.json")
+
+	j, err := NewJournal(log, NewFifoCache(2), path, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.Set("a", order("a"))
+	update := func(o models.Order) models.Order {
+		o.TrackNumber = "updated"
+		return o
+	}
+	j.Update("a", update)
+	// Запись о ключе, которого нет в кэше, не дописывается
+	j.Update("missing", update)
+	if n := journalLines(t, path); n != 2 {
+		t.Errorf("journal has %d records, want 2", n)
+	}
+
+	restored := NewFifoCache(2)
+	if err := RestoreJournal(log, restored, path); err != nil {
+		t.Fatalf("RestoreJournal() error = %v", err)
+	}
+	if o, _ := restored.Peek("a"); o.TrackNumber != "updated" {
+		t.Errorf("restored order a = %q, want updated", o.TrackNumber)
+	}
+	if _, ok := restored.Peek("missing"); ok {
+		t.Error("missing key was restored")
+	}
+}
+
+func TestJournal_Compaction(t *testing.T) {
+	log := slog.New(slog.DiscardHandler)
+	path := filepath.Join(t.TempDir(), "cache.json")
+
+	j, err := NewJournal(log, NewFifoCache(2), path, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, uid := range []string{"a", "b", "c"} {
+		j.Set(uid, order(uid))
+	}
+	// set a, set b, evict a, set c: сжатие после Set c, на котором записей стало 4
+	if n := journalLines(t, path); n != 0 {
+		t.Errorf("journal has %d records after compaction, want 0", n)
+	}
+	snapshot, err := LoadSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Seq != 4 || len(snapshot.Entries) != 2 {
+		t.Errorf("snapshot seq = %d with %d entries, want seq 4 with 2", snapshot.Seq, len(snapshot.Entries))
+	}
+
+	if err := j.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if n := journalLines(t, path); n != 0 {
+		t.Errorf("journal has %d records after Close, want 0", n)
+	}
+
+	// Новый журнал продолжает нумерацию, старые записи не применяются повторно
+	restored := NewFifoCache(2)
+	if err := RestoreJournal(log, restored, path); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := restored.Keys(), []string{"b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys() = %v, want %v", got, want)
+	}
+	j2, err := NewJournal(log, restored, path, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j2.Set("d", order("d"))
+	if j2.seq <= snapshot.Seq {
+		t.Errorf("journal seq restarted at %d", j2.seq)
+	}
+}
+
+func TestJournal_DeleteScrubsFiles(t *testing.T) {
+	log := slog.New(slog.DiscardHandler)
+	path := filepath.Join(t.TempDir(), "cache.json")
+	secret := func(uid string) models.Order {
+		return models.Order{OrderUID: uid, Delivery: models.Delivery{Email: uid + "@secret.example"}}
+	}
+
+	// a попадает в снимок при сжатии, b и c остаются в журнале
+	j, err := NewJournal(log, NewFifoCache(3), path, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.Set("a", secret("a"))
+	j.compactEvery = 1000
+	for _, uid := range []string{"b", "c"} {
+		j.Set(uid, secret(uid))
+	}
+	j.Delete("a")
+	j.Delete("b")
+	j.Set("d", secret("d"))
+	want := j.Keys()
+
+	for _, file := range []string{path, JournalPath(path)} {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, uid := range []string{"a", "b"} {
+			if strings.Contains(string(data), uid+"@secret.example") {
+				t.Errorf("%s still contains data of deleted order %s", filepath.Base(file), uid)
+			}
+		}
+	}
+
+	// Порядок кольца сохраняется: ключи остаются на своих местах до записи delete
+	restored := NewFifoCache(3)
+	if err := RestoreJournal(log, restored, path); err != nil {
+		t.Fatalf("RestoreJournal() error = %v", err)
+	}
+	if got := restored.Keys(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys() = %v, want %v", got, want)
+	}
+	if got, _ := restored.Peek("c"); got.Delivery.Email != "c@secret.example" {
+		t.Errorf("order c = %+v, want untouched", got)
+	}
+}
+
+func TestJournal_DeleteScrubsOnlyWrittenKeys(t *testing.T) {
+	log := slog.New(slog.DiscardHandler)
+	path := filepath.Join(t.TempDir(), "cache.json")
+	secret := func(uid string) models.Order {
+		return models.Order{OrderUID: uid, Delivery: models.Delivery{Email: uid + "@secret.example"}}
+	}
+
+	j, err := NewJournal(log, NewFifoCache(1), path, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a вытеснен, но его заказ остался в журнале
+	j.Set("a", secret("a"))
+	j.Set("b", secret("b"))
+	if j.Delete("a") {
+		t.Error("Delete() of an evicted key = true")
+	}
+	data, err := os.ReadFile(JournalPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "a@secret.example") {
+		t.Error("journal still contains data of evicted and deleted order a")
+	}
+
+	// Ключ, который журнал не записывал, не трогает файлы: если бы они читались,
+	// заказ x в снимке был бы стерт
+	foreign := Snapshot{Version: snapshotVersion, Entries: []SnapshotEntry{{Key: "x", Order: secret("x")}}}
+	encoded, err := json.Marshal(foreign)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, encoded, 0644); err != nil {
+		t.Fatal(err)
+	}
+	j.Delete("x")
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, encoded) {
+		t.Error("Delete() of an unknown key rewrote the snapshot")
+	}
+}
+
+func TestJournal_WritesAfterClose(t *testing.T) {
+	log := slog.New(slog.DiscardHandler)
+	path := filepath.Join(t.TempDir(), "cache.json")
+	secret := func(uid string) models.Order {
+		return models.Order{OrderUID: uid, Delivery: models.Delivery{Email: uid + "@secret.example"}}
+	}
+
+	j, err := NewJournal(log, NewFifoCache(3), path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.Set("a", secret("a"))
+	if err := j.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := j.Close(); err != nil {
+		t.Errorf("second Close() error = %v", err)
+	}
+
+	// Записи после Close не попадают в журнал и не запускают сжатие
+	for _, uid := range []string{"b", "c", "d"} {
+		j.Set(uid, secret(uid))
+	}
+	if n := journalLines(t, path); n != 0 {
+		t.Errorf("journal has %d records after Close, want 0", n)
+	}
+	snapshot, err := LoadSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshot.Entries) != 1 || snapshot.Entries[0].Key != "a" {
+		t.Errorf("snapshot entries = %+v, want only a", snapshot.Entries)
+	}
+
+	// Удаление после Close стирает заказ из снимка
+	j.Delete("a")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "a@secret.example") {
+		t.Error("snapshot still contains data of order a deleted after Close")
+	}
+}
+
+func TestRestoreJournal_SkipsStaleAndTornRecords(t *testing.T) {
+	log := slog.New(slog.DiscardHandler)
+	path := filepath.Join(t.TempDir(), "cache.json")
+
+	c := NewFifoCache(3)
+	c.Set("a", order("a"))
+	if err := saveSnapshot(path, c, 2); err != nil {
+		t.Fatal(err)
+	}
+	// Запись 2 уже в снимке (падение между снимком и очисткой журнала), последняя строка оборвана
+	journal := `{"seq":2,"op":"delete","key":"a"}
+{"seq":3,"op":"set","key":"b","order":{"order_uid":"b"}}
+{"seq":4,"op":"set","key":"c","ord`
+	if err := os.WriteFile(JournalPath(path), []byte(journal), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := NewFifoCache(3)
+	if err := RestoreJournal(log, restored, path); err != nil {
+		t.Fatalf("RestoreJournal() error = %v", err)
+	}
+	if got, want := restored.Keys(), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys() = %v, want %v", got, want)
+	}
+
+	if err := RestoreJournal(log, NewFifoCache(3), filepath.Join(t.TempDir(), "absent.json")); err == nil {
+		t.Error("RestoreJournal() without snapshot and journal expected error")
+	}
+}
@@ -0,0 +1,186 @@
+package cache
+
+import (
+	"container/list"
+	"sort"
+	"sync"
+	"test/internal/models"
+)
+
+// LFUCache вытесняет ключи с наименьшим числом обращений,
+// среди них - самый давно использованный
+type LFUCache struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	// Списки ключей по частоте обращений, в начале списка - самый старый ключ
+	freqs   map[int]*list.List
+	minFreq int
+	evictHook
+}
+
+type lfuEntry struct {
+	key  string
+	val  models.Order
+	freq int
+}
+
+func NewLFUCache(capacity int) *LFUCache {
+	return &LFUCache{
+		capacity: capacity,
+		items:    make(map[string]*list.Element, capacity),
+		freqs:    make(map[int]*list.List),
+	}
+}
+
+func (c *LFUCache) Set(key string, val models.Order) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		el.Value.(*lfuEntry).val = val
+		c.touch(el)
+		return
+	}
+	if len(c.items) >= c.capacity {
+		bucket := c.freqs[c.minFreq]
+		oldest := bucket.Front()
+		c.remove(oldest)
+		c.evicted(oldest.Value.(*lfuEntry).key)
+	}
+	c.items[key] = c.bucket(1).PushBack(&lfuEntry{key: key, val: val, freq: 1})
+	c.minFreq = 1
+}
+
+func (c *LFUCache) Get(key string) (models.Order, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return models.Order{}, false
+	}
+	entry := el.Value.(*lfuEntry)
+	c.touch(el)
+	return entry.val, true
+}
+
+func (c *LFUCache) Peek(key string) (models.Order, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return models.Order{}, false
+	}
+	return el.Value.(*lfuEntry).val, true
+}
+
+func (c *LFUCache) Update(key string, fn func(models.Order) models.Order) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return false
+	}
+	entry := el.Value.(*lfuEntry)
+	entry.val = fn(entry.val)
+	return true
+}
+
+func (c *LFUCache) Delete(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return false
+	}
+	c.remove(el)
+	return true
+}
+
+func (c *LFUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.items)
+}
+
+// Resize при уменьшении вытесняет ключи с наименьшим числом обращений, поэтому
+// новый заказ, который еще не читали, уходит раньше старого часто читаемого
+func (c *LFUCache) Resize(capacity int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.capacity = capacity
+	for len(c.items) > capacity {
+		oldest := c.freqs[c.minFreq].Front()
+		c.remove(oldest)
+		c.evicted(oldest.Value.(*lfuEntry).key)
+	}
+}
+
+func (c *LFUCache) Keys() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	freqs := make([]int, 0, len(c.freqs))
+	for freq := range c.freqs {
+		freqs = append(freqs, freq)
+	}
+	sort.Ints(freqs)
+
+	keys := make([]string, 0, len(c.items))
+	for _, freq := range freqs {
+		for el := c.freqs[freq].Front(); el != nil; el = el.Next() {
+			keys = append(keys, el.Value.(*lfuEntry).key)
+		}
+	}
+	return keys
+}
+
+// touch переносит ключ в список следующей частоты
+func (c *LFUCache) touch(el *list.Element) {
+	entry := el.Value.(*lfuEntry)
+	c.unlink(el)
+	if entry.freq == c.minFreq && c.freqs[entry.freq] == nil {
+		c.minFreq++
+	}
+	entry.freq++
+	c.items[entry.key] = c.bucket(entry.freq).PushBack(entry)
+}
+
+func (c *LFUCache) remove(el *list.Element) {
+	entry := el.Value.(*lfuEntry)
+	c.unlink(el)
+	delete(c.items, entry.key)
+	if c.freqs[c.minFreq] == nil {
+		c.minFreq = 0
+		for freq := range c.freqs {
+			if c.minFreq == 0 || freq < c.minFreq {
+				c.minFreq = freq
+			}
+		}
+	}
+}
+
+// unlink убирает элемент из списка его частоты, пустые списки удаляются
+func (c *LFUCache) unlink(el *list.Element) {
+	freq := el.Value.(*lfuEntry).freq
+	bucket := c.freqs[freq]
+	bucket.Remove(el)
+	if bucket.Len() == 0 {
+		delete(c.freqs, freq)
+	}
+}
+
+func (c *LFUCache) bucket(freq int) *list.List {
+	bucket, ok := c.freqs[freq]
+	if !ok {
+		bucket = list.New()
+		c.freqs[freq] = bucket
+	}
+	return bucket
+}
@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"test/internal/models"
+)
+
+// LRUCache вытесняет ключи, к которым дольше всего не обращались
+type LRUCache struct {
+	mu       sync.Mutex
+	capacity int
+	// Начало списка - самый давно использованный ключ
+	order *list.List
+	items map[string]*list.Element
+	evictHook
+}
+
+type lruEntry struct {
+	key string
+	val models.Order
+}
+
+func NewLRUCache(capacity int) *LRUCache {
+	return &LRUCache{
+		capacity: capacity,
+		order:    list.New(),
+		items:    make(map[string]*list.Element, capacity),
+	}
+}
+
+func (c *LRUCache) Set(key string, val models.Order) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		el.Value.(*lruEntry).val = val
+		c.order.MoveToBack(el)
+		return
+	}
+	if c.order.Len() >= c.capacity {
+		oldest := c.order.Front()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruEntry).key)
+		c.evicted(oldest.Value.(*lruEntry).key)
+	}
+	c.items[key] = c.order.PushBack(&lruEntry{key: key, val: val})
+}
+
+// Get изменяет порядок вытеснения, поэтому берет полную блокировку
+func (c *LRUCache) Get(key string) (models.Order, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return models.Order{}, false
+	}
+	c.order.MoveToBack(el)
+	return el.Value.(*lruEntry).val, true
+}
+
+func (c *LRUCache) Peek(key string) (models.Order, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return models.Order{}, false
+	}
+	return el.Value.(*lruEntry).val, true
+}
+
+func (c *LRUCache) Update(key string, fn func(models.Order) models.Order) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return false
+	}
+	entry := el.Value.(*lruEntry)
+	entry.val = fn(entry.val)
+	return true
+}
+
+func (c *LRUCache) Delete(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return false
+	}
+	c.order.Remove(el)
+	delete(c.items, key)
+	return true
+}
+
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+func (c *LRUCache) Resize(capacity int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.capacity = capacity
+	for c.order.Len() > capacity {
+		oldest := c.order.Front()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruEntry).key)
+		c.evicted(oldest.Value.(*lruEntry).key)
+	}
+}
+
+func (c *LRUCache) Keys() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := make([]string, 0, c.order.Len())
+	for el := c.order.Front(); el != nil; el = el.Next() {
+		keys = append(keys, el.Value.(*lruEntry).key)
+	}
+	return keys
+}
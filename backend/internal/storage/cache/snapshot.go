@@ -0,0 +1,156 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"test/internal/logger"
+	"test/internal/models"
+	"test/internal/storage"
+	"time"
+)
+
+// snapshotVersion меняется при несовместимом изменении формата снимка
+const snapshotVersion = 1
+
+// Snapshot - содержимое cache.json в режимах snapshot и journal
+type Snapshot struct {
+	Version int `json:"version"`
+	// Номер последней операции журнала, вошедшей в снимок
+	Seq uint64 `json:"seq,omitempty"`
+	// Записи в порядке вытеснения: первая будет вытеснена первой
+	Entries []SnapshotEntry `json:"entries"`
+}
+
+type SnapshotEntry struct {
+	Key   string       `json:"key"`
+	Order models.Order `json:"order"`
+}
+
+// SaveSnapshot атомарно записывает содержимое c в path
+func SaveSnapshot(path string, c Cache) error {
+	return saveSnapshot(path, c, 0)
+}
+
+func saveSnapshot(path string, c Cache, seq uint64) error {
+	snapshot := Snapshot{Version: snapshotVersion, Seq: seq, Entries: []SnapshotEntry{}}
+	for _, key := range c.Keys() {
+		if order, ok := c.Peek(key); ok {
+			snapshot.Entries = append(snapshot.Entries, SnapshotEntry{Key: key, Order: order})
+		}
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// LoadSnapshot читает снимок. Файл другой версии или в формате списка ключей - ошибка
+func LoadSnapshot(path string) (*Snapshot, error) {
+	const op = "storage.cache.LoadSnapshot"
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if snapshot.Version != snapshotVersion {
+		return nil, fmt.Errorf("%s: unsupported snapshot version %d, want %d", op, snapshot.Version, snapshotVersion)
+	}
+	return &snapshot, nil
+}
+
+// RestoreSnapshot заполняет c из снимка в сохраненном порядке, без обращения к БД
+func RestoreSnapshot(log *slog.Logger, c Cache, path string) error {
+	const op = "storage.cache.RestoreSnapshot"
+
+	snapshot, err := LoadSnapshot(path)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	for _, entry := range snapshot.Entries {
+		c.Set(entry.Key, entry.Order)
+	}
+	log.Info("cache is restored from snapshot", slog.Int("saved", len(snapshot.Entries)), slog.Int("loaded", c.Len()))
+	return nil
+}
+
+// Revalidate сверяет кэш с БД: удаленные из БД заказы вытесняются, измененные обновляются.
+// Если БД недоступна, попытки повторяются каждые retry, пока не отменен ctx
+func Revalidate(ctx context.Context, log *slog.Logger, c Cache, store storage.OrderStore, retry time.Duration) error {
+	const op = "storage.cache.Revalidate"
+
+	for {
+		err := revalidate(ctx, log, c, store)
+		if err == nil {
+			return nil
+		}
+		log.Warn("cache revalidation failed, retrying", slog.Duration("retry", retry), logger.Err(err))
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s: %w", op, ctx.Err())
+		case <-time.After(retry):
+		}
+	}
+}
+
+// Консьюмеры пишут в кэш во время сверки, поэтому запись, изменившаяся после чтения
+// ключей, не удаляется, а заказ заменяется только более новой версией из БД. Сравнение
+// с текущим значением повторяется внутри Update, под блокировкой кэша
+func revalidate(ctx context.Context, log *slog.Logger, c Cache, store storage.OrderStore) error {
+	keys := c.Keys()
+	if len(keys) == 0 {
+		return nil
+	}
+	before := make(map[string]models.Order, len(keys))
+	for _, key := range keys {
+		if order, ok := c.Peek(key); ok {
+			before[key] = order
+		}
+	}
+	orders, err := store.GetDataToRestoreCache(ctx, keys)
+	if err != nil {
+		return err
+	}
+
+	var removed, updated int
+	for _, key := range keys {
+		cached, ok := c.Peek(key)
+		if !ok || !sameOrder(cached, before[key]) {
+			continue
+		}
+		stored, ok := orders[key]
+		if !ok {
+			c.Delete(key)
+			removed++
+			continue
+		}
+		if !stored.UpdatedAt.After(cached.UpdatedAt) {
+			continue
+		}
+		replaced := false
+		c.Update(key, func(cur models.Order) models.Order {
+			if !stored.UpdatedAt.After(cur.UpdatedAt) {
+				return cur
+			}
+			replaced = true
+			return stored
+		})
+		if replaced {
+			updated++
+		}
+	}
+	log.Info("cache is revalidated", slog.Int("checked", len(keys)), slog.Int("removed", removed), slog.Int("updated", updated))
+	return nil
+}
+
+// sameOrder сравнивает версии заказа: каждое сохранение в БД обновляет updated_at
+func sameOrder(a, b models.Order) bool {
+	return a.UpdatedAt.Equal(b.UpdatedAt)
+}
@@ -0,0 +1,209 @@
+package cache
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"test/internal/models"
+	"test/internal/storage"
+	"test/internal/storage/memory"
+)
+
+func TestSnapshot_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	c := NewSnapshotPersistent(slog.New(slog.DiscardHandler), NewLRUCache(3), path)
+	for _, uid := range []string{"a", "b", "c"} {
+		if uid == "c" {
+			// Чтение меняет порядок LRU, снимок пишется при следующем изменении
+			c.Get("a")
+		}
+		o := order(uid)
+		o.TrackNumber = "track-" + uid
+		c.Set(uid, o)
+	}
+
+	restored := NewLRUCache(3)
+	if err := RestoreSnapshot(slog.New(slog.DiscardHandler), restored, path); err != nil {
+		t.Fatalf("RestoreSnapshot() error = %v", err)
+	}
+	// Порядок вытеснения сохраняется, значения берутся из снимка без БД
+	if got, want := restored.Keys(), []string{"b", "a", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys() = %v, want %v", got, want)
+	}
+	if o, ok := restored.Peek("b"); !ok || o.TrackNumber != "track-b" {
+		t.Errorf("Peek(b) = %+v, %v", o, ok)
+	}
+
+	// Во время записи рядом не остается временных файлов
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory contains %d files, want only cache.json", len(entries))
+	}
+}
+
+func TestLoadSnapshot_RejectsOtherFormats(t *testing.T) {
+	dir := t.TempDir()
+
+	keysPath := filepath.Join(dir, "keys.json")
+	if err := SaveCacheMetaData(keysPath, []string{"a"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSnapshot(keysPath); err == nil {
+		t.Error("LoadSnapshot() of a key list expected error")
+	}
+
+	versionPath := filepath.Join(dir, "v2.json")
+	if err := os.WriteFile(versionPath, []byte(`{"version":2,"entries":[]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSnapshot(versionPath); err == nil {
+		t.Error("LoadSnapshot() of an unknown version expected error")
+	}
+}
+
+func TestRevalidate(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	for _, uid := range []string{"a", "b"} {
+		o := order(uid)
+		if err := store.NewDataLoad(ctx, &o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fresh, err := store.GetOrderByUID(ctx, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewFifoCache(3)
+	stale := *fresh
+	stale.UpdatedAt = fresh.UpdatedAt.Add(-time.Hour)
+	stale.TrackNumber = "stale"
+	aOrder, _ := store.GetOrderByUID(ctx, "a")
+	c.Set("a", *aOrder)
+	c.Set("b", stale)
+	// Заказ deleted есть только в снимке
+	c.Set("deleted", order("deleted"))
+
+	if err := Revalidate(ctx, slog.New(slog.DiscardHandler), c, store, time.Millisecond); err != nil {
+		t.Fatalf("Revalidate() error = %v", err)
+	}
+	if got, want := c.Keys(), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys() = %v, want %v", got, want)
+	}
+	if o, _ := c.Peek("b"); o.TrackNumber == "stale" {
+		t.Error("stale order b was not refreshed")
+	}
+}
+
+// racingStore после чтения из БД записывает заказы в кэш, как консьюмер во время сверки
+type racingStore struct {
+	storage.OrderStore
+	cache  Cache
+	writes []models.Order
+}
+
+func (s *racingStore) GetDataToRestoreCache(ctx context.Context, uids []string) (map[string]models.Order, error) {
+	orders, err := s.OrderStore.GetDataToRestoreCache(ctx, uids)
+	for _, o := range s.writes {
+		s.cache.Set(o.OrderUID, o)
+	}
+	return orders, err
+}
+
+func TestRevalidate_KeepsNewerCachedOrders(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	a := order("a")
+	if err := store.NewDataLoad(ctx, &a); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := store.GetOrderByUID(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fresh := *stored
+	fresh.UpdatedAt = stored.UpdatedAt.Add(time.Hour)
+	fresh.TrackNumber = "fresh"
+
+	// Версия в кэше новее, чем в БД
+	c := NewFifoCache(3)
+	c.Set("a", fresh)
+	if err := Revalidate(ctx, slog.New(slog.DiscardHandler), c, store, time.Millisecond); err != nil {
+		t.Fatalf("Revalidate() error = %v", err)
+	}
+	if o, _ := c.Peek("a"); o.TrackNumber != "fresh" {
+		t.Errorf("order a = %q, newer cached version was overwritten", o.TrackNumber)
+	}
+
+	// Консьюмер записал заказы в кэш после чтения из БД
+	c = NewFifoCache(3)
+	c.Set("a", *stored)
+	c.Set("late", order("late"))
+	late := order("late")
+	late.TrackNumber = "fresh"
+	late.UpdatedAt = time.Now()
+	racing := &racingStore{OrderStore: store, cache: c, writes: []models.Order{fresh, late}}
+	if err := Revalidate(ctx, slog.New(slog.DiscardHandler), c, racing, time.Millisecond); err != nil {
+		t.Fatalf("Revalidate() error = %v", err)
+	}
+	if o, _ := c.Peek("a"); o.TrackNumber != "fresh" {
+		t.Errorf("order a = %q, want the version written during revalidation", o.TrackNumber)
+	}
+	if o, ok := c.Peek("late"); !ok || o.TrackNumber != "fresh" {
+		t.Error("order late written during revalidation was removed")
+	}
+}
+
+// peekRacingCache обновляет заказ сразу после второго Peek ключа, как UpdateStatus,
+// попавший между сравнением версий и записью при сверке
+type peekRacingCache struct {
+	*FifoCache
+	peeks int
+	fresh models.Order
+}
+
+func (c *peekRacingCache) Peek(key string) (models.Order, bool) {
+	order, ok := c.FifoCache.Peek(key)
+	if key == c.fresh.OrderUID {
+		if c.peeks++; c.peeks == 2 {
+			c.FifoCache.Update(key, func(models.Order) models.Order { return c.fresh })
+		}
+	}
+	return order, ok
+}
+
+func TestRevalidate_KeepsUpdateBetweenCompareAndWrite(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	a := order("a")
+	if err := store.NewDataLoad(ctx, &a); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := store.GetOrderByUID(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale := *stored
+	stale.UpdatedAt = stored.UpdatedAt.Add(-time.Hour)
+	fresh := *stored
+	fresh.UpdatedAt = stored.UpdatedAt.Add(time.Hour)
+	fresh.TrackNumber = "fresh"
+
+	c := &peekRacingCache{FifoCache: NewFifoCache(2), fresh: fresh}
+	c.Set("a", stale)
+	if err := Revalidate(ctx, slog.New(slog.DiscardHandler), c, store, time.Millisecond); err != nil {
+		t.Fatalf("Revalidate() error = %v", err)
+	}
+	if o, _ := c.FifoCache.Peek("a"); o.TrackNumber != "fresh" {
+		t.Errorf("order a = %q, update made during revalidation was overwritten", o.TrackNumber)
+	}
+}
@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"test/internal/models"
+	"test/internal/storage"
+)
+
+// Стратегии прогрева, значение cache_params.warm_up
+const (
+	WarmUpNone        = "none"
+	WarmUpDateCreated = storage.SortByDateCreated
+	WarmUpCreatedAt   = storage.SortByCreatedAt
+)
+
+// Warming - обертка кэша на время прогрева. Прогрев пишет копии заказов, прочитанные
+// из БД заранее, поэтому ключи, которые сервис записал, обновил или удалил после
+// создания обертки, запоминаются и прогревом не трогаются: иначе он вернул бы в кэш
+// устаревший статус или удаленный заказ. Создается до запуска консьюмеров
+type Warming struct {
+	Cache
+	mu      sync.Mutex
+	done    bool
+	touched map[string]bool
+}
+
+func NewWarming(c Cache) *Warming {
+	return &Warming{
+		Cache:   c,
+		touched: make(map[string]bool),
+	}
+}
+
+func (w *Warming) Set(key string, val models.Order) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.touch(key)
+	w.Cache.Set(key, val)
+}
+
+func (w *Warming) Update(key string, fn func(models.Order) models.Order) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.touch(key)
+	return w.Cache.Update(key, fn)
+}
+
+func (w *Warming) Delete(key string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.touch(key)
+	return w.Cache.Delete(key)
+}
+
+// touch вызывается под w.mu
+func (w *Warming) touch(key string) {
+	if !w.done {
+		w.touched[key] = true
+	}
+}
+
+// fill добавляет заказ прогрева, если сервис не трогал его ключ и ключа нет в кэше.
+// Проверка и запись идут под w.mu, поэтому удаление не может оказаться между ними
+func (w *Warming) fill(order models.Order) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.touched[order.OrderUID] {
+		return false
+	}
+	if _, ok := w.Cache.Peek(order.OrderUID); ok {
+		return false
+	}
+	w.Cache.Set(order.OrderUID, order)
+	return true
+}
+
+// finish завершает прогрев: ключи больше не запоминаются
+func (w *Warming) finish() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.done = true
+	w.touched = nil
+}
+
+// WarmUp загружает в c не больше limit последних заказов по полю sortBy.
+// Ключи, которые сервис трогал во время прогрева (см. Warming), и ключи, которые уже
+// есть в кэше, не перезаписываются. Заказы добавляются от старых к новым, чтобы самые
+// свежие вытеснялись последними
+func WarmUp(ctx context.Context, log *slog.Logger, c *Warming, store storage.OrderStore, sortBy string, limit int) error {
+	const op = "storage.cache.WarmUp"
+
+	defer c.finish()
+	if limit <= 0 {
+		return nil
+	}
+	orders, err := store.GetRecentOrders(ctx, sortBy, limit)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	loaded := 0
+	for i := len(orders) - 1; i >= 0; i-- {
+		if c.fill(orders[i]) {
+			loaded++
+		}
+	}
+	log.Info("cache is warmed up", slog.String("sort_by", sortBy), slog.Int("loaded", loaded), slog.Int("size", c.Len()))
+	return nil
+}
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+
+	"test/internal/models"
+	"test/internal/storage"
+	"test/internal/storage/memory"
+)
+
+func TestWarmUp(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, uid := range []string{"a", "b", "c", "d"} {
+		o := &models.Order{OrderUID: uid, DateCreated: base.Add(time.Duration(i) * time.Hour)}
+		if err := store.NewDataLoad(ctx, o); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := NewWarming(NewFifoCache(3))
+	// Ключ d уже записан консьюмером и не перезаписывается прогревом
+	c.Set("d", models.Order{OrderUID: "d", TrackNumber: "from-consumer"})
+
+	if err := WarmUp(ctx, slog.New(slog.DiscardHandler), c, store, storage.SortByDateCreated, 3); err != nil {
+		t.Fatalf("WarmUp() error = %v", err)
+	}
+	// Загружены b и c (d уже был), старые заказы вытесняются первыми
+	if got, want := c.Keys(), []string{"d", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys() = %v, want %v", got, want)
+	}
+	if o, _ := c.Peek("d"); o.TrackNumber != "from-consumer" {
+		t.Error("WarmUp() overwrote an order already in the cache")
+	}
+
+	if err := WarmUp(ctx, slog.New(slog.DiscardHandler), NewWarming(NewFifoCache(3)), store, "price", 3); err == nil {
+		t.Error("WarmUp() with unknown sort field expected error")
+	}
+}
+
+// erasingStore после чтения последних заказов меняет и удаляет их через кэш, как консьюмеры во время прогрева
+type erasingStore struct {
+	storage.OrderStore
+	cache *Warming
+}
+
+func (s *erasingStore) GetRecentOrders(ctx context.Context, sortBy string, limit int) ([]models.Order, error) {
+	orders, err := s.OrderStore.GetRecentOrders(ctx, sortBy, limit)
+	s.cache.Update("a", func(o models.Order) models.Order { return o })
+	s.cache.Delete("b")
+	return orders, err
+}
+
+func TestWarmUp_SkipsKeysTouchedDuringWarmUp(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	for _, uid := range []string{"a", "b", "c"} {
+		o := order(uid)
+		if err := store.NewDataLoad(ctx, &o); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := NewWarming(NewFifoCache(3))
+	racing := &erasingStore{OrderStore: store, cache: c}
+	if err := WarmUp(ctx, slog.New(slog.DiscardHandler), c, racing, storage.SortByDateCreated, 3); err != nil {
+		t.Fatalf("WarmUp() error = %v", err)
+	}
+	// Статус a обновлен, а b удален после чтения из БД: прочитанные копии устарели
+	if got, want := c.Keys(), []string{"c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys() = %v, want %v", got, want)
+	}
+
+	// После прогрева ключи больше не запоминаются
+	c.Delete("c")
+	if c.touched != nil {
+		t.Errorf("touched = %v after warm-up, want nil", c.touched)
+	}
+}
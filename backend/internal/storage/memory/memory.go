@@ -0,0 +1,278 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"test/internal/models"
+	"test/internal/storage"
+	"time"
+)
+
+// Storage - хранилище заказов в памяти с той же семантикой, что и postgres.Storage:
+// order_uid уникален, повторная запись заказа заменяет его вместе с дочерними записями,
+// id и created_at заказа сохраняются. Предназначено для тестов
+type Storage struct {
+	mu     sync.RWMutex
+	orders map[string]models.Order
+	audits []models.ErasureAudit
+	lastID uint
+}
+
+var _ storage.OrderStore = (*Storage)(nil)
+
+func New() *Storage {
+	return &Storage{
+		orders: make(map[string]models.Order),
+	}
+}
+
+func (s *Storage) NewDataLoad(_ context.Context, order *models.Order) error {
+	const op = "storage.memory.NewDataLoad"
+
+	if order.OrderUID == "" {
+		return fmt.Errorf("%s: %w: order_uid violates not-null constraint", op, storage.ErrRejected)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	stored := cloneOrder(*order)
+	if existing, ok := s.orders[order.OrderUID]; ok {
+		stored.ID = existing.ID
+		stored.CreatedAt = existing.CreatedAt
+	} else {
+		stored.ID = s.nextID()
+		stored.CreatedAt = now
+	}
+	stored.UpdatedAt = now
+
+	stored.Delivery.ID, stored.Delivery.OrderID = s.nextID(), stored.ID
+	stored.Delivery.CreatedAt, stored.Delivery.UpdatedAt = now, now
+	stored.Payment.ID, stored.Payment.OrderID = s.nextID(), stored.ID
+	stored.Payment.CreatedAt, stored.Payment.UpdatedAt = now, now
+	for i := range stored.Items {
+		stored.Items[i].ID, stored.Items[i].OrderID = s.nextID(), stored.ID
+		stored.Items[i].CreatedAt, stored.Items[i].UpdatedAt = now, now
+	}
+
+	s.orders[stored.OrderUID] = stored
+	*order = cloneOrder(stored)
+	return nil
+}
+
+func (s *Storage) GetOrderByUID(_ context.Context, orderUID string) (*models.Order, error) {
+	const op = "storage.memory.GetOrderByUID"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	order, ok := s.orders[orderUID]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrOrderNotFound)
+	}
+	order = cloneOrder(order)
+	return &order, nil
+}
+
+func (s *Storage) GetDataToRestoreCache(_ context.Context, uids []string) (map[string]models.Order, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	orders := make(map[string]models.Order)
+	for _, uid := range uids {
+		if order, ok := s.orders[uid]; ok {
+			orders[uid] = cloneOrder(order)
+		}
+	}
+	return orders, nil
+}
+
+func (s *Storage) GetRecentOrders(_ context.Context, sortBy string, limit int) ([]models.Order, error) {
+	const op = "storage.memory.GetRecentOrders"
+
+	var key func(models.Order) time.Time
+	switch sortBy {
+	case storage.SortByDateCreated:
+		key = func(o models.Order) time.Time { return o.DateCreated }
+	case storage.SortByCreatedAt:
+		key = func(o models.Order) time.Time { return o.CreatedAt }
+	default:
+		return nil, fmt.Errorf("%s: unknown sort field %q", op, sortBy)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	orders := make([]models.Order, 0, len(s.orders))
+	for _, order := range s.orders {
+		orders = append(orders, cloneOrder(order))
+	}
+	// При равном времени порядок как у postgres-реализации: по id по убыванию
+	sort.Slice(orders, func(i, j int) bool {
+		ki, kj := key(orders[i]), key(orders[j])
+		if !ki.Equal(kj) {
+			return ki.After(kj)
+		}
+		return orders[i].ID > orders[j].ID
+	})
+	if limit >= 0 && len(orders) > limit {
+		orders = orders[:limit]
+	}
+	return orders, nil
+}
+
+func (s *Storage) SearchOrders(_ context.Context, query storage.SearchQuery) (*storage.SearchResult, error) {
+	const op = "storage.memory.SearchOrders"
+
+	if err := query.Normalize(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	cursor, err := query.DecodeCursor()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.mu.RLock()
+	var found []models.Order
+	for _, order := range s.orders {
+		if matches(&query.Filter, &order) && (cursor == nil || cursor.After(&query, &order)) {
+			found = append(found, cloneOrder(order))
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(found, func(i, j int) bool {
+		ki, kj := query.SortKey(&found[i]), query.SortKey(&found[j])
+		if !ki.Equal(kj) {
+			return ki.Before(kj) == query.Ascending
+		}
+		return (found[i].ID < found[j].ID) == query.Ascending
+	})
+
+	result := &storage.SearchResult{Orders: []models.Order{}}
+	if len(found) > query.Limit {
+		found = found[:query.Limit]
+		result.NextCursor = query.NewCursor(&found[len(found)-1])
+	}
+	for _, order := range found {
+		// Без WithDetails дочерние записи не загружаются, как в postgres-реализации
+		if !query.WithDetails {
+			order.Delivery, order.Payment, order.Items = models.Delivery{}, models.Payment{}, nil
+		}
+		result.Orders = append(result.Orders, order)
+	}
+	return result, nil
+}
+
+func (s *Storage) UpdateItemStatus(_ context.Context, orderUID, rid string, status int, check func(from int) error) (*models.Order, error) {
+	const op = "storage.memory.UpdateItemStatus"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[orderUID]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrOrderNotFound)
+	}
+	// Изменения применяются к копии и сохраняются, только если все переходы разрешены
+	order = cloneOrder(order)
+	now := time.Now()
+	changed := 0
+	for i := range order.Items {
+		item := &order.Items[i]
+		if rid != "" && item.RID != rid {
+			continue
+		}
+		if err := check(item.Status); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		item.Status, item.UpdatedAt = status, now
+		changed++
+	}
+	if changed == 0 {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrItemNotFound)
+	}
+	order.UpdatedAt = now
+
+	s.orders[orderUID] = order
+	order = cloneOrder(order)
+	return &order, nil
+}
+
+func (s *Storage) EraseOrder(_ context.Context, audit *models.ErasureAudit) error {
+	const op = "storage.memory.EraseOrder"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[audit.OrderUID]
+	if !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrOrderNotFound)
+	}
+	switch audit.Mode {
+	case models.ErasureDelete:
+		delete(s.orders, audit.OrderUID)
+	case models.ErasureAnonymize:
+		order = cloneOrder(order)
+		order.CustomerID = ""
+		order.Delivery = models.Delivery{
+			ID: order.Delivery.ID, OrderID: order.Delivery.OrderID,
+			CreatedAt: order.Delivery.CreatedAt, UpdatedAt: time.Now(),
+		}
+		order.UpdatedAt = time.Now()
+		s.orders[audit.OrderUID] = order
+	default:
+		return fmt.Errorf("%s: unknown erasure mode %q", op, audit.Mode)
+	}
+
+	audit.ID = s.nextID()
+	if audit.CreatedAt.IsZero() {
+		audit.CreatedAt = time.Now()
+	}
+	s.audits = append(s.audits, *audit)
+	return nil
+}
+
+// Audits возвращает журнал удалений
+func (s *Storage) Audits() []models.ErasureAudit {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]models.ErasureAudit(nil), s.audits...)
+}
+
+func matches(f *storage.OrderFilter, o *models.Order) bool {
+	if f.CustomerID != "" && o.CustomerID != f.CustomerID ||
+		f.TrackNumber != "" && o.TrackNumber != f.TrackNumber ||
+		f.DeliveryService != "" && o.DeliveryService != f.DeliveryService ||
+		f.Locale != "" && o.Locale != f.Locale ||
+		!f.DateFrom.IsZero() && o.DateCreated.Before(f.DateFrom) ||
+		!f.DateTo.IsZero() && !o.DateCreated.Before(f.DateTo) ||
+		f.Provider != "" && o.Payment.Provider != f.Provider ||
+		f.Currency != "" && o.Payment.Currency != f.Currency {
+		return false
+	}
+	if f.Brand == "" {
+		return true
+	}
+	for _, item := range o.Items {
+		if item.Brand == f.Brand {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Storage) nextID() uint {
+	s.lastID++
+	return s.lastID
+}
+
+// cloneOrder копирует заказ вместе со слайсом Items, чтобы вызывающий код не менял хранилище
+func cloneOrder(order models.Order) models.Order {
+	order.Items = append([]models.Item(nil), order.Items...)
+	return order
+}
@@ -0,0 +1,241 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"test/internal/models"
+	"test/internal/storage"
+	"testing"
+	"time"
+)
+
+func TestStorage_NewDataLoad(t *testing.T) {
+	s := New()
+
+	first := &models.Order{
+		OrderUID: "b563feb7b2b84b6test",
+		Items:    []models.Item{{ChrtID: 1}, {ChrtID: 2}},
+	}
+	if err := s.NewDataLoad(context.Background(), first); err != nil {
+		t.Fatalf("NewDataLoad() error = %v", err)
+	}
+	if first.ID == 0 || first.Items[0].OrderID != first.ID {
+		t.Fatalf("ids are not assigned: %+v", first)
+	}
+
+	// Повторная запись с тем же order_uid заменяет заказ, но сохраняет id
+	second := &models.Order{
+		OrderUID:    "b563feb7b2b84b6test",
+		TrackNumber: "CORRECTED",
+		Items:       []models.Item{{ChrtID: 3}},
+	}
+	if err := s.NewDataLoad(context.Background(), second); err != nil {
+		t.Fatalf("NewDataLoad() upsert error = %v", err)
+	}
+	if second.ID != first.ID || !second.CreatedAt.Equal(first.CreatedAt) {
+		t.Errorf("upsert changed id or created_at: %d/%v, want %d/%v", second.ID, second.CreatedAt, first.ID, first.CreatedAt)
+	}
+
+	got, err := s.GetOrderByUID(context.Background(), "b563feb7b2b84b6test")
+	if err != nil {
+		t.Fatalf("GetOrderByUID() error = %v", err)
+	}
+	if got.TrackNumber != "CORRECTED" || len(got.Items) != 1 || got.Items[0].ChrtID != 3 {
+		t.Errorf("stored order = %+v, want corrected version", got)
+	}
+
+	// Изменение возвращенного заказа не затрагивает хранилище
+	got.Items[0].ChrtID = 100
+	again, _ := s.GetOrderByUID(context.Background(), "b563feb7b2b84b6test")
+	if again.Items[0].ChrtID != 3 {
+		t.Error("storage shares items with caller")
+	}
+
+	if err := s.NewDataLoad(context.Background(), &models.Order{}); err == nil {
+		t.Error("NewDataLoad() with empty order_uid expected error")
+	}
+}
+
+func TestStorage_GetOrderByUID_NotFound(t *testing.T) {
+	_, err := New().GetOrderByUID(context.Background(), "unknown")
+	if !errors.Is(err, storage.ErrOrderNotFound) {
+		t.Errorf("GetOrderByUID() error = %v, want ErrOrderNotFound", err)
+	}
+}
+
+func TestStorage_GetDataToRestoreCache(t *testing.T) {
+	s := New()
+	for _, uid := range []string{"a", "b"} {
+		if err := s.NewDataLoad(context.Background(), &models.Order{OrderUID: uid}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	orders, err := s.GetDataToRestoreCache(context.Background(), []string{"a", "b", "missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 2 {
+		t.Errorf("got %d orders, want 2", len(orders))
+	}
+}
+
+func TestStorage_GetRecentOrders(t *testing.T) {
+	s := New()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Заказы сохраняются не в порядке date_created
+	for _, uid := range []string{"b", "c", "a"} {
+		created := map[string]int{"a": 0, "b": 1, "c": 2}[uid]
+		order := &models.Order{OrderUID: uid, DateCreated: base.Add(time.Duration(created) * time.Hour)}
+		if err := s.NewDataLoad(context.Background(), order); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := s.GetRecentOrders(context.Background(), storage.SortByDateCreated, 2)
+	if err != nil {
+		t.Fatalf("GetRecentOrders() error = %v", err)
+	}
+	if len(got) != 2 || got[0].OrderUID != "c" || got[1].OrderUID != "b" {
+		t.Errorf("by date_created = %v, want [c b]", uids(got))
+	}
+
+	got, err = s.GetRecentOrders(context.Background(), storage.SortByCreatedAt, 10)
+	if err != nil {
+		t.Fatalf("GetRecentOrders() error = %v", err)
+	}
+	// Время сохранения может совпасть, тогда последним сохраненным считается больший id
+	if len(got) != 3 || got[0].OrderUID != "a" || got[2].OrderUID != "b" {
+		t.Errorf("by created_at = %v, want [a c b]", uids(got))
+	}
+
+	if _, err := s.GetRecentOrders(context.Background(), "price", 1); err == nil {
+		t.Error("GetRecentOrders() with unknown field expected error")
+	}
+}
+
+func TestStorage_SearchOrders(t *testing.T) {
+	s := New()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, uid := range []string{"a", "b", "c", "d", "e"} {
+		order := &models.Order{
+			OrderUID:    uid,
+			CustomerID:  "test",
+			DateCreated: base.Add(time.Duration(i) * time.Hour),
+			Payment:     models.Payment{Provider: "wbpay", Currency: "USD"},
+			Items:       []models.Item{{ChrtID: i, Brand: "Vivienne Sabo"}},
+		}
+		if uid == "c" {
+			order.CustomerID = "other"
+			order.Payment.Currency = "RUB"
+			order.Items[0].Brand = "Nike"
+		}
+		if err := s.NewDataLoad(context.Background(), order); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Постраничный обход по убыванию date_created
+	query := storage.SearchQuery{Filter: storage.OrderFilter{CustomerID: "test"}, Limit: 2}
+	var pages [][]string
+	for {
+		res, err := s.SearchOrders(context.Background(), query)
+		if err != nil {
+			t.Fatalf("SearchOrders() error = %v", err)
+		}
+		pages = append(pages, uids(res.Orders))
+		if res.NextCursor == "" {
+			break
+		}
+		query.Cursor = res.NextCursor
+	}
+	if len(pages) != 2 || len(pages[0]) != 2 || pages[0][0] != "e" || pages[0][1] != "d" ||
+		len(pages[1]) != 2 || pages[1][0] != "b" || pages[1][1] != "a" {
+		t.Errorf("pages = %v, want [[e d] [b a]]", pages)
+	}
+
+	tests := []struct {
+		name   string
+		filter storage.OrderFilter
+		want   int
+	}{
+		{name: "currency", filter: storage.OrderFilter{Currency: "RUB"}, want: 1},
+		{name: "brand", filter: storage.OrderFilter{Brand: "Vivienne Sabo"}, want: 4},
+		{name: "date range", filter: storage.OrderFilter{DateFrom: base.Add(time.Hour), DateTo: base.Add(3 * time.Hour)}, want: 2},
+		{name: "provider and customer", filter: storage.OrderFilter{Provider: "wbpay", CustomerID: "other"}, want: 1},
+		{name: "no match", filter: storage.OrderFilter{Locale: "en"}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := s.SearchOrders(context.Background(), storage.SearchQuery{Filter: tt.filter})
+			if err != nil {
+				t.Fatalf("SearchOrders() error = %v", err)
+			}
+			if len(res.Orders) != tt.want || res.NextCursor != "" {
+				t.Errorf("got %v (cursor %q), want %d orders", uids(res.Orders), res.NextCursor, tt.want)
+			}
+		})
+	}
+
+	res, err := s.SearchOrders(context.Background(), storage.SearchQuery{SortBy: storage.SortByCreatedAt, Ascending: true, Limit: 1, WithDetails: true})
+	if err != nil {
+		t.Fatalf("SearchOrders() error = %v", err)
+	}
+	if len(res.Orders) != 1 || res.Orders[0].OrderUID != "a" || len(res.Orders[0].Items) != 1 {
+		t.Errorf("ascending with details = %+v, want order a with items", res.Orders)
+	}
+
+	// Курсор привязан к сортировке, с которой был выдан
+	if _, err := s.SearchOrders(context.Background(), storage.SearchQuery{Cursor: res.NextCursor}); !errors.Is(err, storage.ErrInvalidCursor) {
+		t.Errorf("cursor with another sort: error = %v, want ErrInvalidCursor", err)
+	}
+	if _, err := s.SearchOrders(context.Background(), storage.SearchQuery{Cursor: "garbage"}); !errors.Is(err, storage.ErrInvalidCursor) {
+		t.Errorf("garbage cursor: error = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestStorage_UpdateItemStatus(t *testing.T) {
+	s := New()
+	order := &models.Order{OrderUID: "a", Items: []models.Item{{RID: "r1", Status: 202}, {RID: "r2", Status: 203}}}
+	if err := s.NewDataLoad(context.Background(), order); err != nil {
+		t.Fatal(err)
+	}
+	only202 := func(from int) error {
+		if from != 202 {
+			return errors.New("not allowed")
+		}
+		return nil
+	}
+
+	// Второй товар не проходит проверку, поэтому первый тоже не меняется
+	if _, err := s.UpdateItemStatus(context.Background(), "a", "", 204, only202); err == nil {
+		t.Fatal("UpdateItemStatus() expected error from check")
+	}
+	got, _ := s.GetOrderByUID(context.Background(), "a")
+	if got.Items[0].Status != 202 || !got.UpdatedAt.Equal(order.UpdatedAt) {
+		t.Errorf("failed update changed order: %+v", got)
+	}
+
+	updated, err := s.UpdateItemStatus(context.Background(), "a", "r1", 204, only202)
+	if err != nil {
+		t.Fatalf("UpdateItemStatus() error = %v", err)
+	}
+	if updated.Items[0].Status != 204 || updated.Items[1].Status != 203 || updated.UpdatedAt.Before(order.UpdatedAt) {
+		t.Errorf("updated order = %+v", updated)
+	}
+
+	if _, err := s.UpdateItemStatus(context.Background(), "a", "r9", 204, only202); !errors.Is(err, storage.ErrItemNotFound) {
+		t.Errorf("unknown rid: error = %v, want ErrItemNotFound", err)
+	}
+	if _, err := s.UpdateItemStatus(context.Background(), "b", "", 204, only202); !errors.Is(err, storage.ErrOrderNotFound) {
+		t.Errorf("unknown order: error = %v, want ErrOrderNotFound", err)
+	}
+}
+
+func uids(orders []models.Order) []string {
+	uids := make([]string, 0, len(orders))
+	for _, o := range orders {
+		uids = append(uids, o.OrderUID)
+	}
+	return uids
+}
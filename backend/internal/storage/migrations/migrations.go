@@ -0,0 +1,241 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//go:embed sql/*.sql
+var embedded embed.FS
+
+// Имя файла миграции: <версия>_<название>.<up|down>.sql
+var fileRe = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Ключ advisory lock, чтобы несколько экземпляров сервиса не применяли миграции одновременно
+const lockKey = 7_318_442_001
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status - состояние миграции в базе
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Load читает пары up/down файлов из fsys и возвращает миграции по возрастанию версии
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	const op = "storage.migrations.Load"
+
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		m := fileRe.FindStringSubmatch(entry.Name())
+		if m == nil {
+			return nil, fmt.Errorf("%s: unexpected file name %q", op, entry.Name())
+		}
+		version, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", op, entry.Name(), err)
+		}
+		body, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		}
+		if mig.Name != m[2] {
+			return nil, fmt.Errorf("%s: version %d has two names: %s and %s", op, version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(body)
+		} else {
+			mig.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" || mig.Down == "" {
+			return nil, fmt.Errorf("%s: migration %d_%s must have both up and down files", op, mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrator применяет и откатывает миграции, учет ведется в таблице schema_migrations
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// New создает мигратор со встроенными в бинарник миграциями
+func New(db *sql.DB) (*Migrator, error) {
+	migrations, err := Load(embedded, "sql")
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+	}, nil
+}
+
+// Up применяет все еще не примененные миграции, каждую в своей транзакции
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	const op = "storage.migrations.Up"
+
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var applied []Migration
+	for _, mig := range m.migrations {
+		ok, err := m.inTx(ctx, func(tx *sql.Tx) (bool, error) {
+			done, err := isApplied(ctx, tx, mig.Version)
+			if err != nil || done {
+				return false, err
+			}
+			if _, err := tx.ExecContext(ctx, mig.Up); err != nil {
+				return false, err
+			}
+			_, err = tx.ExecContext(ctx,
+				`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, mig.Version, mig.Name)
+			return err == nil, err
+		})
+		if err != nil {
+			return applied, fmt.Errorf("%s: %d_%s: %w", op, mig.Version, mig.Name, err)
+		}
+		if ok {
+			applied = append(applied, mig)
+		}
+	}
+	return applied, nil
+}
+
+// Down откатывает steps последних примененных миграций
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	const op = "storage.migrations.Down"
+
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var rolledBack []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(rolledBack) < steps; i-- {
+		mig := m.migrations[i]
+		ok, err := m.inTx(ctx, func(tx *sql.Tx) (bool, error) {
+			done, err := isApplied(ctx, tx, mig.Version)
+			if err != nil || !done {
+				return false, err
+			}
+			if _, err := tx.ExecContext(ctx, mig.Down); err != nil {
+				return false, err
+			}
+			_, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, mig.Version)
+			return err == nil, err
+		})
+		if err != nil {
+			return rolledBack, fmt.Errorf("%s: %d_%s: %w", op, mig.Version, mig.Name, err)
+		}
+		if ok {
+			rolledBack = append(rolledBack, mig)
+		}
+	}
+	return rolledBack, nil
+}
+
+// Status возвращает все известные миграции с отметкой о применении
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	const op = "storage.migrations.Status"
+
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := m.db.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	appliedAt := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		appliedAt[version] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		at, ok := appliedAt[mig.Version]
+		statuses = append(statuses, Status{Migration: mig, Applied: ok, AppliedAt: at})
+	}
+	return statuses, nil
+}
+
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    BIGINT PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	return err
+}
+
+// inTx выполняет fn в транзакции под advisory lock
+func (m *Migrator) inTx(ctx context.Context, fn func(tx *sql.Tx) (bool, error)) (bool, error) {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, lockKey); err != nil {
+		return false, err
+	}
+	ok, err := fn(tx)
+	if err != nil {
+		return false, err
+	}
+	return ok, tx.Commit()
+}
+
+func isApplied(ctx context.Context, tx *sql.Tx, version int64) (bool, error) {
+	var exists bool
+	err := tx.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, version).Scan(&exists)
+	return exists, err
+}
@@ -0,0 +1,79 @@
+package migrations
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoad_Embedded(t *testing.T) {
+	migrations, err := Load(embedded, "sql")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no embedded migrations")
+	}
+	for i, mig := range migrations {
+		if i > 0 && migrations[i-1].Version >= mig.Version {
+			t.Errorf("migrations are not ordered: %d before %d", migrations[i-1].Version, mig.Version)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    fstest.MapFS
+		wantErr  bool
+		wantVers []int64
+	}{
+		{
+			name: "sorted by version",
+			files: fstest.MapFS{
+				"sql/0010_add_index.up.sql":   {Data: []byte("CREATE INDEX ...")},
+				"sql/0010_add_index.down.sql": {Data: []byte("DROP INDEX ...")},
+				"sql/0002_add_table.up.sql":   {Data: []byte("CREATE TABLE ...")},
+				"sql/0002_add_table.down.sql": {Data: []byte("DROP TABLE ...")},
+			},
+			wantVers: []int64{2, 10},
+		},
+		{
+			name: "missing down file",
+			files: fstest.MapFS{
+				"sql/0001_init.up.sql": {Data: []byte("CREATE TABLE ...")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "same version with different names",
+			files: fstest.MapFS{
+				"sql/0001_init.up.sql":    {Data: []byte("CREATE TABLE ...")},
+				"sql/0001_other.down.sql": {Data: []byte("DROP TABLE ...")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unexpected file name",
+			files: fstest.MapFS{
+				"sql/init.sql": {Data: []byte("CREATE TABLE ...")},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := Load(tt.files, "sql")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(migrations) != len(tt.wantVers) {
+				t.Fatalf("got %d migrations, want %d", len(migrations), len(tt.wantVers))
+			}
+			for i, mig := range migrations {
+				if mig.Version != tt.wantVers[i] {
+					t.Errorf("migrations[%d].Version = %d, want %d", i, mig.Version, tt.wantVers[i])
+				}
+			}
+		})
+	}
+}
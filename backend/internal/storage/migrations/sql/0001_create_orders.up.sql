@@ -0,0 +1,74 @@
+-- Начальная схема, совпадает с тем, что создавал gorm AutoMigrate,
+-- поэтому на уже существующей базе миграция ничего не меняет
+CREATE TABLE IF NOT EXISTS orders (
+    id                 BIGSERIAL PRIMARY KEY,
+    order_uid          VARCHAR(255) NOT NULL,
+    track_number       VARCHAR(255),
+    entry              VARCHAR(25),
+    locale             VARCHAR(25),
+    internal_signature VARCHAR(255),
+    customer_id        VARCHAR(255),
+    delivery_service   VARCHAR(50),
+    shard_key          VARCHAR(10),
+    sm_id              BIGINT,
+    date_created       TIMESTAMPTZ NOT NULL,
+    oof_shard          VARCHAR(10),
+    created_at         TIMESTAMPTZ,
+    updated_at         TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_order_uid ON orders (order_uid);
+
+CREATE TABLE IF NOT EXISTS deliveries (
+    id         BIGSERIAL PRIMARY KEY,
+    order_id   BIGINT       NOT NULL,
+    name       VARCHAR(255) NOT NULL,
+    phone      VARCHAR(50),
+    zip        VARCHAR(20),
+    city       VARCHAR(100),
+    address    VARCHAR(500),
+    region     VARCHAR(100),
+    email      VARCHAR(255),
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    CONSTRAINT fk_orders_delivery FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_deliveries_order_id ON deliveries (order_id);
+
+CREATE TABLE IF NOT EXISTS payments (
+    id            BIGSERIAL PRIMARY KEY,
+    order_id      BIGINT       NOT NULL,
+    transaction   VARCHAR(255) NOT NULL,
+    request_id    VARCHAR(255),
+    currency      VARCHAR(10),
+    provider      VARCHAR(100),
+    amount        BIGINT,
+    payment_dt    BIGINT,
+    bank          VARCHAR(100),
+    delivery_cost BIGINT,
+    goods_total   BIGINT,
+    custom_fee    BIGINT,
+    created_at    TIMESTAMPTZ,
+    updated_at    TIMESTAMPTZ,
+    CONSTRAINT fk_orders_payment FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_payments_order_id ON payments (order_id);
+
+CREATE TABLE IF NOT EXISTS items (
+    id           BIGSERIAL PRIMARY KEY,
+    order_id     BIGINT NOT NULL,
+    chrt_id      BIGINT NOT NULL,
+    track_number VARCHAR(255),
+    price        BIGINT,
+    r_id         VARCHAR(255),
+    name         VARCHAR(255),
+    sale         BIGINT,
+    size         VARCHAR(50),
+    total_price  BIGINT,
+    nm_id        BIGINT,
+    brand        VARCHAR(255),
+    status       BIGINT,
+    created_at   TIMESTAMPTZ,
+    updated_at   TIMESTAMPTZ,
+    CONSTRAINT fk_orders_items FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_items_order_id ON items (order_id);
@@ -0,0 +1,3 @@
+-- Индексы для прогрева кэша последними заказами (GetRecentOrders)
+CREATE INDEX IF NOT EXISTS idx_orders_date_created ON orders (date_created DESC);
+CREATE INDEX IF NOT EXISTS idx_orders_created_at ON orders (created_at DESC);
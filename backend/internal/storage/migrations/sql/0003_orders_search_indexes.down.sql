@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_items_brand;
+DROP INDEX IF EXISTS idx_payments_order_id_provider;
+DROP INDEX IF EXISTS idx_orders_track_number;
+DROP INDEX IF EXISTS idx_orders_customer_id;
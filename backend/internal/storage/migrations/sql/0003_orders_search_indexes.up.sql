@@ -0,0 +1,5 @@
+-- Индексы для фильтров поиска заказов (SearchOrders)
+CREATE INDEX IF NOT EXISTS idx_orders_customer_id ON orders (customer_id);
+CREATE INDEX IF NOT EXISTS idx_orders_track_number ON orders (track_number);
+CREATE INDEX IF NOT EXISTS idx_payments_order_id_provider ON payments (order_id, provider);
+CREATE INDEX IF NOT EXISTS idx_items_brand ON items (brand);
@@ -0,0 +1,11 @@
+-- Журнал удалений заказов по запросам на удаление персональных данных.
+-- Внешнего ключа на orders нет: запись должна пережить сам заказ
+CREATE TABLE IF NOT EXISTS order_erasures (
+    id           BIGSERIAL PRIMARY KEY,
+    order_uid    VARCHAR(255) NOT NULL,
+    mode         VARCHAR(20)  NOT NULL,
+    source       VARCHAR(20)  NOT NULL,
+    requested_by VARCHAR(255),
+    created_at   TIMESTAMPTZ  NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_order_erasures_order_uid ON order_erasures (order_uid);
@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"test/internal/logger"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// Запросы дольше этого порога пишутся в лог как медленные
+const slowQueryThreshold = 200 * time.Millisecond
+
+// gormLogger перенаправляет логи gorm в slog
+type gormLogger struct {
+	log *slog.Logger
+}
+
+func newGormLogger(log *slog.Logger) gormlogger.Interface {
+	return &gormLogger{log: log}
+}
+
+// Уровень задается самим slog логгером, поэтому LogMode ничего не меняет
+func (l *gormLogger) LogMode(gormlogger.LogLevel) gormlogger.Interface {
+	return l
+}
+
+func (l *gormLogger) Info(ctx context.Context, msg string, args ...any) {
+	l.log.InfoContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (l *gormLogger) Warn(ctx context.Context, msg string, args ...any) {
+	l.log.WarnContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (l *gormLogger) Error(ctx context.Context, msg string, args ...any) {
+	l.log.ErrorContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	elapsed := time.Since(begin)
+	switch {
+	// Отсутствие записи - штатная ситуация, ее обрабатывает вызывающий код
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		l.log.ErrorContext(ctx, "query failed",
+			slog.String("sql", sql), slog.Int64("rows", rows), slog.Duration("elapsed", elapsed), logger.Err(err))
+	case elapsed > slowQueryThreshold:
+		sql, rows := fc()
+		l.log.WarnContext(ctx, "slow query",
+			slog.String("sql", sql), slog.Int64("rows", rows), slog.Duration("elapsed", elapsed))
+	case l.log.Enabled(ctx, slog.LevelDebug):
+		sql, rows := fc()
+		l.log.DebugContext(ctx, "query",
+			slog.String("sql", sql), slog.Int64("rows", rows), slog.Duration("elapsed", elapsed))
+	}
+}
@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"test/internal/models"
+	"test/internal/storage"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// dryRunDB строит запросы без подключения к БД
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// tableColumns возвращает колонки таблицы из начальной миграции
+func tableColumns(t *testing.T, table string) map[string]bool {
+	t.Helper()
+	data, err := os.ReadFile("../migrations/sql/0001_create_orders.up.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := regexp.MustCompile(`(?s)CREATE TABLE IF NOT EXISTS ` + table + ` \((.*?)\n\);`).FindStringSubmatch(string(data))
+	if body == nil {
+		t.Fatalf("table %s not found in migration", table)
+	}
+	columns := make(map[string]bool)
+	for _, line := range strings.Split(body[1], "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 && fields[0] != "CONSTRAINT" {
+			columns[fields[0]] = true
+		}
+	}
+	return columns
+}
+
+func TestOrderItems_UsesSchemaColumns(t *testing.T) {
+	columns := tableColumns(t, "items")
+
+	for _, rid := range []string{"", "ab4219087a764ae0btest"} {
+		stmt := orderItems(dryRunDB(t), 1, rid).Find(&[]models.Item{}).Statement
+		sql := stmt.SQL.String()
+		// Колонки условий, в кавычках или без: "items"."r_id" = $2, order_id = $1
+		used := regexp.MustCompile(`"?(\w+)"? = \$\d+`).FindAllStringSubmatch(sql, -1)
+		if len(used) == 0 {
+			t.Fatalf("no columns in %s", sql)
+		}
+		for _, m := range used {
+			if !columns[m[1]] {
+				t.Errorf("query uses column %q that migration does not create: %s", m[1], sql)
+			}
+		}
+		if hasRID := strings.Contains(sql, `"r_id"`); hasRID != (rid != "") {
+			t.Errorf("rid %q: query %s", rid, sql)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		wantRejected bool
+	}{
+		{name: "unique violation", err: &pgconn.PgError{Code: "23505"}, wantRejected: true},
+		{name: "value too long", err: fmt.Errorf("insert: %w", &pgconn.PgError{Code: "22001"}), wantRejected: true},
+		{name: "connection lost", err: &pgconn.PgError{Code: "08006"}},
+		{name: "network error", err: errors.New("dial tcp 127.0.0.1:5432: connect: connection refused")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := classify(tt.err)
+			if errors.Is(err, storage.ErrRejected) != tt.wantRejected {
+				t.Errorf("classify() = %v, rejected want %v", err, tt.wantRejected)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("classify() lost the original error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"test/internal/models"
+	"time"
+)
+
+// ErrInvalidCursor возвращается, если курсор поиска поврежден или выдан для другой сортировки
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+// OrderFilter - условия поиска, пустые поля не фильтруют
+type OrderFilter struct {
+	CustomerID      string
+	TrackNumber     string
+	DeliveryService string
+	Locale          string
+	// Диапазон date_created: [DateFrom, DateTo), нулевое значение - без границы
+	DateFrom time.Time
+	DateTo   time.Time
+	// Поля оплаты
+	Provider string
+	Currency string
+	// Заказ подходит, если хотя бы один товар этого бренда
+	Brand string
+}
+
+// SearchQuery - запрос поиска заказов
+type SearchQuery struct {
+	Filter OrderFilter
+	// SortByDateCreated (по умолчанию) или SortByCreatedAt; при равенстве - по id
+	SortBy string
+	// По умолчанию новые первыми
+	Ascending bool
+	// 0 - DefaultSearchLimit, больше MaxSearchLimit не отдается
+	Limit int
+	// NextCursor предыдущей страницы, пусто - первая страница
+	Cursor string
+	// Загружать Delivery, Payment и Items
+	WithDetails bool
+}
+
+// SearchResult - страница результатов. NextCursor пустой на последней странице
+type SearchResult struct {
+	Orders     []models.Order
+	NextCursor string
+}
+
+// Normalize подставляет значения по умолчанию и проверяет сортировку
+func (q *SearchQuery) Normalize() error {
+	if q.SortBy == "" {
+		q.SortBy = SortByDateCreated
+	}
+	if q.SortBy != SortByDateCreated && q.SortBy != SortByCreatedAt {
+		return fmt.Errorf("unknown sort field %q", q.SortBy)
+	}
+	if q.Limit <= 0 {
+		q.Limit = DefaultSearchLimit
+	}
+	q.Limit = min(q.Limit, MaxSearchLimit)
+	return nil
+}
+
+// SortKey - значение поля сортировки заказа
+func (q *SearchQuery) SortKey(order *models.Order) time.Time {
+	if q.SortBy == SortByCreatedAt {
+		return order.CreatedAt
+	}
+	return order.DateCreated
+}
+
+// Cursor - позиция последнего заказа страницы: значение поля сортировки и id
+type Cursor struct {
+	SortBy    string    `json:"s"`
+	Ascending bool      `json:"a,omitempty"`
+	Time      time.Time `json:"t"`
+	ID        uint      `json:"id"`
+}
+
+// NewCursor - курсор, указывающий на order
+func (q *SearchQuery) NewCursor(order *models.Order) string {
+	data, _ := json.Marshal(Cursor{SortBy: q.SortBy, Ascending: q.Ascending, Time: q.SortKey(order), ID: order.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor разбирает курсор запроса. Пустой курсор - nil
+func (q *SearchQuery) DecodeCursor() (*Cursor, error) {
+	if q.Cursor == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.SortBy != q.SortBy || cursor.Ascending != q.Ascending {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// After сообщает, идет ли order после курсора в порядке сортировки
+func (c *Cursor) After(q *SearchQuery, order *models.Order) bool {
+	key := q.SortKey(order)
+	if q.Ascending {
+		return key.After(c.Time) || (key.Equal(c.Time) && order.ID > c.ID)
+	}
+	return key.Before(c.Time) || (key.Equal(c.Time) && order.ID < c.ID)
+}
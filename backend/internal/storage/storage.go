@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"test/internal/models"
+)
+
+var (
+	// ErrOrderNotFound возвращается хранилищем, если заказа с таким order_uid нет
+	ErrOrderNotFound = errors.New("order not found")
+	// ErrItemNotFound возвращается, если в заказе нет товара с таким rid или нет товаров вовсе
+	ErrItemNotFound = errors.New("item not found")
+	// ErrRejected возвращается, если данные нарушают ограничения схемы: повтор записи не поможет.
+	// Остальные ошибки записи считаются временными (например, БД недоступна)
+	ErrRejected = errors.New("rejected by storage")
+)
+
+// Поля сортировки для GetRecentOrders
+const (
+	// Дата создания заказа из сообщения
+	SortByDateCreated = "date_created"
+	// Время сохранения заказа в хранилище
+	SortByCreatedAt = "created_at"
+)
+
+// OrderStore - хранилище заказов. Реализации: postgres.Storage и memory.Storage (для тестов)
+type OrderStore interface {
+	// NewDataLoad сохраняет заказ; заказ с существующим order_uid заменяется целиком
+	NewDataLoad(ctx context.Context, order *models.Order) error
+	GetOrderByUID(ctx context.Context, orderUID string) (*models.Order, error)
+	GetDataToRestoreCache(ctx context.Context, uids []string) (map[string]models.Order, error)
+	// GetRecentOrders возвращает не больше limit последних заказов по полю sortBy, новые первыми
+	GetRecentOrders(ctx context.Context, sortBy string, limit int) ([]models.Order, error)
+	// SearchOrders возвращает страницу заказов по фильтрам с пагинацией курсором
+	SearchOrders(ctx context.Context, query SearchQuery) (*SearchResult, error)
+	// UpdateItemStatus меняет статус товара rid (пустой rid - всех товаров заказа) и возвращает
+	// обновленный заказ. check вызывается с текущим статусом каждого товара до изменения;
+	// если он вернул ошибку, ничего не меняется и ошибка возвращается вызывающему
+	UpdateItemStatus(ctx context.Context, orderUID, rid string, status int, check func(from int) error) (*models.Order, error)
+	// EraseOrder удаляет или обезличивает заказ audit.OrderUID в зависимости от audit.Mode
+	// и в той же транзакции сохраняет audit в журнал удалений
+	EraseOrder(ctx context.Context, audit *models.ErasureAudit) error
+}
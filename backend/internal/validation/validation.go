@@ -0,0 +1,164 @@
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"test/internal/models"
+	"time"
+	"unicode/utf8"
+)
+
+// FieldError - ошибка конкретного поля заказа, Field задается путем из json имен (items[0].rid)
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) String() string {
+	return e.Field + ": " + e.Message
+}
+
+// Errors - список ошибок полей, возвращается как error
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, 0, len(e))
+	for _, fe := range e {
+		parts = append(parts, fe.String())
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+var (
+	phoneRe = regexp.MustCompile(`^\+?[0-9]{7,15}$`)
+
+	// Валюты, которые принимает сервис (ISO 4217)
+	knownCurrencies = map[string]bool{
+		"USD": true, "EUR": true, "RUB": true, "GBP": true, "CNY": true,
+		"JPY": true, "KZT": true, "BYN": true, "UAH": true, "ILS": true,
+		"TRY": true, "AMD": true, "UZS": true, "KGS": true, "CHF": true,
+	}
+)
+
+// ValidateOrder проверяет заказ перед сохранением: обязательные поля и ограничения длины
+// берутся из gorm тегов моделей, остальное - бизнес-правила. Возвращает Errors или nil
+func ValidateOrder(order *models.Order) error {
+	if order == nil {
+		return Errors{{Field: "order", Message: "is empty"}}
+	}
+
+	var errs Errors
+	errs = checkTags(errs, "", reflect.ValueOf(*order))
+
+	if len(order.Items) == 0 {
+		errs = append(errs, FieldError{Field: "items", Message: "must contain at least one item"})
+	}
+
+	d := order.Delivery
+	if d.Email != "" {
+		if addr, err := mail.ParseAddress(d.Email); err != nil || addr.Address != d.Email {
+			errs = append(errs, FieldError{Field: "delivery.email", Message: "invalid email"})
+		}
+	}
+	if d.Phone != "" && !phoneRe.MatchString(d.Phone) {
+		errs = append(errs, FieldError{Field: "delivery.phone", Message: "invalid phone number"})
+	}
+
+	p := order.Payment
+	if !knownCurrencies[p.Currency] {
+		errs = append(errs, FieldError{Field: "payment.currency", Message: fmt.Sprintf("unknown currency %q", p.Currency)})
+	}
+	errs = nonNegative(errs, "payment.amount", p.Amount)
+	errs = nonNegative(errs, "payment.delivery_cost", p.DeliveryCost)
+	errs = nonNegative(errs, "payment.goods_total", p.GoodsTotal)
+	errs = nonNegative(errs, "payment.custom_fee", p.CustomFee)
+	if p.PaymentDt < 0 {
+		errs = append(errs, FieldError{Field: "payment.payment_dt", Message: "must not be negative"})
+	}
+
+	for i, item := range order.Items {
+		prefix := fmt.Sprintf("items[%d].", i)
+		errs = nonNegative(errs, prefix+"price", item.Price)
+		errs = nonNegative(errs, prefix+"total_price", item.TotalPrice)
+		if item.Sale < 0 || item.Sale > 100 {
+			errs = append(errs, FieldError{Field: prefix + "sale", Message: "must be between 0 and 100"})
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func nonNegative(errs Errors, field string, v int) Errors {
+	if v < 0 {
+		return append(errs, FieldError{Field: field, Message: "must not be negative"})
+	}
+	return errs
+}
+
+// checkTags обходит модель и проверяет поля с json именем по gorm тегам:
+// "not null" - поле обязательно, "size:N" - максимальная длина строки
+func checkTags(errs Errors, prefix string, v reflect.Value) Errors {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		path := prefix + name
+		fv := v.Field(i)
+
+		switch fv.Kind() {
+		case reflect.Struct:
+			if _, ok := fv.Interface().(time.Time); !ok {
+				errs = checkTags(errs, path+".", fv)
+				continue
+			}
+		case reflect.Slice:
+			if fv.Type().Elem().Kind() == reflect.Struct {
+				for j := 0; j < fv.Len(); j++ {
+					errs = checkTags(errs, fmt.Sprintf("%s[%d].", path, j), fv.Index(j))
+				}
+				continue
+			}
+		}
+
+		gormTag := field.Tag.Get("gorm")
+		if hasTag(gormTag, "not null") && fv.IsZero() {
+			errs = append(errs, FieldError{Field: path, Message: "is required"})
+			continue
+		}
+		if size, ok := sizeTag(gormTag); ok && fv.Kind() == reflect.String {
+			if n := utf8.RuneCountInString(fv.String()); n > size {
+				errs = append(errs, FieldError{Field: path, Message: fmt.Sprintf("is longer than %d characters", size)})
+			}
+		}
+	}
+	return errs
+}
+
+func hasTag(tag, option string) bool {
+	for _, part := range strings.Split(tag, ";") {
+		if strings.EqualFold(strings.TrimSpace(part), option) {
+			return true
+		}
+	}
+	return false
+}
+
+func sizeTag(tag string) (int, bool) {
+	for _, part := range strings.Split(tag, ";") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(part), "size:"); ok {
+			size, err := strconv.Atoi(value)
+			return size, err == nil
+		}
+	}
+	return 0, false
+}
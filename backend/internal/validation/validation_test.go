@@ -0,0 +1,106 @@
+package validation
+
+import (
+	"errors"
+	"strings"
+	"test/internal/models"
+	"testing"
+	"time"
+)
+
+func validOrder() *models.Order {
+	return &models.Order{
+		OrderUID:    "b563feb7b2b84b6test",
+		TrackNumber: "WBILMTESTTRACK",
+		Entry:       "WBIL",
+		Locale:      "en",
+		CustomerID:  "test",
+		DateCreated: time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC),
+		Delivery: models.Delivery{
+			Name:  "Test Testov",
+			Phone: "+9720000000",
+			Email: "test@gmail.com",
+		},
+		Payment: models.Payment{
+			Transaction: "b563feb7b2b84b6test",
+			Currency:    "USD",
+			Amount:      1817,
+		},
+		Items: []models.Item{
+			{ChrtID: 9934930, Price: 453, RID: "ab4219087a764ae0btest", Sale: 30, TotalPrice: 317},
+		},
+	}
+}
+
+func TestValidateOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		modify     func(o *models.Order)
+		wantFields []string
+	}{
+		{
+			name:   "valid order",
+			modify: func(o *models.Order) {},
+		},
+		{
+			name:       "required fields from not null tags",
+			modify:     func(o *models.Order) { o.OrderUID = ""; o.Delivery.Name = ""; o.Items[0].ChrtID = 0 },
+			wantFields: []string{"order_uid", "delivery.name", "items[0].chrt_id"},
+		},
+		{
+			name:       "no items",
+			modify:     func(o *models.Order) { o.Items = nil },
+			wantFields: []string{"items"},
+		},
+		{
+			name:       "size limits from gorm tags",
+			modify:     func(o *models.Order) { o.Entry = strings.Repeat("x", 26); o.Items[0].Size = strings.Repeat("x", 51) },
+			wantFields: []string{"entry", "items[0].size"},
+		},
+		{
+			name:       "negative amounts",
+			modify:     func(o *models.Order) { o.Payment.Amount = -1; o.Items[0].Price = -5 },
+			wantFields: []string{"payment.amount", "items[0].price"},
+		},
+		{
+			name: "email, phone and currency formats",
+			modify: func(o *models.Order) {
+				o.Delivery.Email = "not-an-email"
+				o.Delivery.Phone = "call me"
+				o.Payment.Currency = "XYZ"
+			},
+			wantFields: []string{"delivery.email", "delivery.phone", "payment.currency"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := validOrder()
+			tt.modify(order)
+
+			err := ValidateOrder(order)
+			if len(tt.wantFields) == 0 {
+				if err != nil {
+					t.Fatalf("ValidateOrder() error = %v", err)
+				}
+				return
+			}
+
+			var errs Errors
+			if !errors.As(err, &errs) {
+				t.Fatalf("ValidateOrder() error = %v, want Errors", err)
+			}
+			got := make(map[string]bool, len(errs))
+			for _, fe := range errs {
+				got[fe.Field] = true
+			}
+			for _, field := range tt.wantFields {
+				if !got[field] {
+					t.Errorf("missing error for %s, got %v", field, errs)
+				}
+			}
+			if len(errs) != len(tt.wantFields) {
+				t.Errorf("got %d errors, want %d: %v", len(errs), len(tt.wantFields), errs)
+			}
+		})
+	}
+}
@@ -2,11 +2,14 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"html/template"
 	"log"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/segmentio/kafka-go"
@@ -16,8 +19,64 @@ var (
 	messageChan = make(chan string, 100)
 )
 
+// Заголовки, по которым бэкенд связывает запрос order_id с ответом
+const (
+	headerCorrelationID = "correlation_id"
+	headerReplyTo       = "reply_to"
+)
+
 type KafkaProducer struct {
-	writers map[string]*kafka.Writer
+	writers    map[string]*kafka.Writer
+	replies    *replyRouter
+	replyTopic string
+}
+
+// replyRouter раздает ответы из order_response ожидающим запросам по correlation_id
+type replyRouter struct {
+	mu      sync.Mutex
+	waiters map[string]chan string
+}
+
+func newReplyRouter() *replyRouter {
+	return &replyRouter{
+		waiters: make(map[string]chan string),
+	}
+}
+
+// wait регистрирует ожидание ответа; cancel нужно вызвать, когда ответ больше не нужен
+func (r *replyRouter) wait(correlationID string) (reply <-chan string, cancel func()) {
+	ch := make(chan string, 1)
+	r.mu.Lock()
+	r.waiters[correlationID] = ch
+	r.mu.Unlock()
+
+	return ch, func() {
+		r.mu.Lock()
+		delete(r.waiters, correlationID)
+		r.mu.Unlock()
+	}
+}
+
+// deliver отдает ответ ожидающему запросу, возвращает false, если его никто не ждет
+func (r *replyRouter) deliver(correlationID, reply string) bool {
+	r.mu.Lock()
+	ch, ok := r.waiters[correlationID]
+	delete(r.waiters, correlationID)
+	r.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+	ch <- reply
+	return true
+}
+
+func newCorrelationID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
 }
 
 type PageData struct {
@@ -33,28 +92,52 @@ var (
 	responseMessages = make([]string, 0)
 )
 
-func NewKafkaProducer(brokers []string, topics []string) *KafkaProducer {
+func NewKafkaProducer(brokers []string, topics []string, replies *replyRouter, replyTopic string) *KafkaProducer {
 	writers := make(map[string]*kafka.Writer)
 
 	for _, topic := range topics {
 		writer := &kafka.Writer{
 			Addr:  kafka.TCP(brokers...),
 			Topic: topic,
+			// Сообщения одного заказа попадают в одну партицию
+			Balancer: &kafka.Hash{},
 		}
 		writers[topic] = writer
 	}
 
 	return &KafkaProducer{
-		writers: writers,
+		writers:    writers,
+		replies:    replies,
+		replyTopic: replyTopic,
+	}
+}
+
+// messageKey - order_uid заказа: сервис обрабатывает сообщения одного ключа по порядку.
+// Если order_uid не найден, ключом остается время отправки
+func messageKey(topic string, raw []byte) []byte {
+	switch topic {
+	case "order_id":
+		if len(raw) > 0 {
+			return raw
+		}
+	case "json_data":
+		var order struct {
+			OrderUID string `json:"order_uid"`
+		}
+		if json.Unmarshal(raw, &order) == nil && order.OrderUID != "" {
+			return []byte(order.OrderUID)
+		}
 	}
+	return []byte(fmt.Sprintf("%d", time.Now().Unix()))
 }
 
 // SendMessage отправляет сырые байты, без обёрток и повторной сериализации.
 // Для json_data (опционально) валидирует, что message — валидный JSON.
-func (kp *KafkaProducer) SendMessage(ctx context.Context, topic, message string) error {
+// Для order_id дожидается ответа с тем же correlation_id и возвращает его.
+func (kp *KafkaProducer) SendMessage(ctx context.Context, topic, message string) (string, error) {
 	writer, exists := kp.writers[topic]
 	if !exists {
-		return fmt.Errorf("writer for topic %s not found", topic)
+		return "", fmt.Errorf("writer for topic %s not found", topic)
 	}
 
 	raw := []byte(message)
@@ -63,7 +146,7 @@ func (kp *KafkaProducer) SendMessage(ctx context.Context, topic, message string)
 	if topic == "json_data" {
 		var tmp json.RawMessage
 		if err := json.Unmarshal(raw, &tmp); err != nil {
-			return fmt.Errorf("invalid JSON for topic %s: %w", topic, err)
+			return "", fmt.Errorf("invalid JSON for topic %s: %w", topic, err)
 		}
 	}
 
@@ -74,20 +157,46 @@ func (kp *KafkaProducer) SendMessage(ctx context.Context, topic, message string)
 	}
 	log.Printf("Sending raw payload to topic %s: %s", topic, logPreview)
 
+	correlationID, err := newCorrelationID()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate correlation id: %w", err)
+	}
+
 	// Создаем Kafka сообщение
 	kafkaMessage := kafka.Message{
-		Key:   []byte(fmt.Sprintf("%d", time.Now().Unix())),
+		Key:   messageKey(topic, raw),
 		Value: raw,
 		Time:  time.Now(),
+		Headers: []kafka.Header{
+			{Key: headerCorrelationID, Value: []byte(correlationID)},
+			{Key: headerReplyTo, Value: []byte(kp.replyTopic)},
+		},
+	}
+
+	// Ожидание регистрируем до отправки, чтобы не пропустить быстрый ответ
+	var reply <-chan string
+	if topic == "order_id" {
+		var cancel func()
+		reply, cancel = kp.replies.wait(correlationID)
+		defer cancel()
 	}
 
 	// Отправляем сообщение
 	if err := writer.WriteMessages(ctx, kafkaMessage); err != nil {
-		return fmt.Errorf("failed to write message to topic %s: %w", topic, err)
+		return "", fmt.Errorf("failed to write message to topic %s: %w", topic, err)
 	}
 
-	log.Printf("Message sent to topic %s successfully", topic)
-	return nil
+	log.Printf("Message sent to topic %s successfully (correlation_id=%s)", topic, correlationID)
+	if reply == nil {
+		return "", nil
+	}
+
+	select {
+	case text := <-reply:
+		return text, nil
+	case <-ctx.Done():
+		return "", fmt.Errorf("no reply for correlation_id %s: %w", correlationID, ctx.Err())
+	}
 }
 
 func (kp *KafkaProducer) Close() error {
@@ -130,7 +239,7 @@ func checkTopics(brokers []string, topics []string) error {
 	return nil
 }
 
-func startResponseConsumer(brokers []string, topic string) {
+func startResponseConsumer(brokers []string, topic string, replies *replyRouter) {
 	reader := kafka.NewReader(kafka.ReaderConfig{
 		Brokers: brokers,
 		GroupID: "response-consumer-group",
@@ -150,6 +259,14 @@ func startResponseConsumer(brokers []string, topic string) {
 			text := string(msg.Value)
 			log.Printf("Received response message: %s", text)
 
+			// Ответ на запрос этого процесса отдаем ожидающему SendMessage
+			for _, h := range msg.Headers {
+				if h.Key == headerCorrelationID {
+					replies.deliver(string(h.Value), text)
+					break
+				}
+			}
+
 			// Отправляем в SSE канал
 			select {
 			case messageChan <- text:
@@ -169,8 +286,11 @@ func main() {
 		log.Printf("Warning: Could not verify topics: %v", err)
 	}
 
+	// Ответы order_response раздаются запросам по correlation_id
+	replies := newReplyRouter()
+
 	// Создаем Kafka producer
-	kafkaProducer := NewKafkaProducer(brokers, topics)
+	kafkaProducer := NewKafkaProducer(brokers, topics, replies, "order_response")
 	defer func() {
 		if err := kafkaProducer.Close(); err != nil {
 			log.Printf("Error closing Kafka producer: %v", err)
@@ -178,7 +298,7 @@ func main() {
 	}()
 
 	// Запускаем consumer, который слушает order_response и сохраняет сообщения
-	startResponseConsumer(brokers, "order_response")
+	startResponseConsumer(brokers, "order_response", replies)
 
 	// Парсим HTML шаблон
 	tmpl, err := template.ParseFiles("templates/index.html")
@@ -207,13 +327,16 @@ func main() {
 				ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 				defer cancel()
 
-				if err := kafkaProducer.SendMessage(ctx, topic, message); err != nil {
+				if reply, err := kafkaProducer.SendMessage(ctx, topic, message); err != nil {
 					data.Status = "error"
 					data.Message = fmt.Sprintf("Ошибка отправки: %v", err)
 					log.Printf("Error sending message: %v", err)
 				} else {
 					data.Status = fmt.Sprintf("Сообщение успешно отправлено в топик '%s'", topic)
 					data.Message = message // Сохраняем отправленное сообщение для формы
+					if reply != "" {
+						data.ResponseMessages = append(data.ResponseMessages, reply)
+					}
 				}
 			}
 		}